@@ -27,6 +27,7 @@ import (
 	"k8s.io/component-base/version/verflag"
 
 	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud"
+	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis"
 )
 
 // RunProviderHCloudManager runs the HCloud machine controller server.
@@ -37,6 +38,13 @@ func RunProviderHCloudManager(args *pflag.FlagSet) error {
 	s := options.NewMCServer()
 
 	s.AddFlags(args)
+	args.DurationVar(&apis.ActionWaitTimeout, "hcloud-action-timeout", apis.ActionWaitTimeout, "Maximum time to wait for HCloud actions to complete")
+
+	orphanCollector := hcloud.NewOrphanCollector()
+	args.DurationVar(&orphanCollector.Interval, "hcloud-orphan-collection-interval", orphanCollector.Interval, "Minimum time between two collections of orphaned floating IPs, volumes and primary IPs of a cluster, which run when MCM lists machines (disabled if 0)")
+	args.DurationVar(&orphanCollector.GracePeriod, "hcloud-orphan-grace-period", orphanCollector.GracePeriod, "Minimum age of orphaned resources to be collected")
+	args.BoolVar(&orphanCollector.DryRun, "hcloud-orphan-dry-run", orphanCollector.DryRun, "Report orphaned resources only instead of deleting them")
+
 	flag.InitFlags()
 
 	verflag.PrintAndExitIfRequested()
@@ -44,5 +52,5 @@ func RunProviderHCloudManager(args *pflag.FlagSet) error {
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
-	return app.Run(s, hcloud.NewHCloudProvider())
+	return app.Run(s, hcloud.NewHCloudProvider(orphanCollector))
 }
@@ -18,16 +18,90 @@ limitations under the License.
 package apis
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/klog/v2"
 )
 
-var singletons = make(map[string]*hcloud.Client)
+// ClientCacheTTL is the duration an unused client is kept in the cache.
+// Clients of rotated tokens are evicted after this duration.
+const ClientCacheTTL = time.Hour
+
+// clientCacheEntry is a cached HCloud client together with its last access time
+type clientCacheEntry struct {
+	client   *hcloud.Client
+	lastUsed time.Time
+	// pinned entries have been set explicitly and are never evicted
+	pinned bool
+}
+
+var (
+	singletons      = make(map[string]*clientCacheEntry)
+	singletonsMutex sync.Mutex
+
+	// httpTransport is shared by all HCloud clients to reuse connections to the API
+	httpTransport = newHTTPTransport()
+
+	clientCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mcm",
+		Subsystem: "hcloud_client_cache",
+		Name:      "requests_total",
+		Help:      "Number of HCloud client cache lookups partitioned by result (hit or miss).",
+	}, []string{"result"})
+	clientCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "mcm",
+		Subsystem: "hcloud_client_cache",
+		Name:      "evictions_total",
+		Help:      "Number of HCloud clients evicted from the cache after being unused for the TTL.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(clientCacheRequests, clientCacheEvictions)
+}
+
+// newHTTPTransport returns the HTTP transport used for all HCloud API requests
+func newHTTPTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 32
+
+	return transport
+}
+
+// getClientCacheKey returns the cache key for the given token. Tokens are not kept in memory as map keys.
+//
+// PARAMETERS
+// token string Token to calculate the cache key for
+func getClientCacheKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// evictExpiredClients removes all clients from the cache not used since the given time.
+// The cache mutex must be held by the caller.
+//
+// PARAMETERS
+// expiry time.Time Time before that a client has to be used last to be evicted
+func evictExpiredClients(expiry time.Time) {
+	for key, entry := range singletons {
+		if !entry.pinned && entry.lastUsed.Before(expiry) {
+			delete(singletons, key)
+			clientCacheEvictions.Inc()
+		}
+	}
+}
 
 // GetClientForToken returns an underlying HCloud client for the given token.
+// Clients are cached and shared between concurrent callers. All requests of a client share the rate limit budget
+// reported by the API for the token.
 //
 // PARAMETERS
 // token string Token to look up client instance for
@@ -38,20 +112,39 @@ func GetClientForToken(token string) *hcloud.Client {
 		token = strings.ReplaceAll(token, "\n", "")
 	}
 
-	client, ok := singletons[token]
+	key := getClientCacheKey(token)
+	now := time.Now()
+
+	singletonsMutex.Lock()
+	defer singletonsMutex.Unlock()
+
+	evictExpiredClients(now.Add(-ClientCacheTTL))
+
+	entry, ok := singletons[key]
+
+	if ok {
+		clientCacheRequests.WithLabelValues("hit").Inc()
+	} else {
+		clientCacheRequests.WithLabelValues("miss").Inc()
 
-	if !ok {
 		opts := []hcloud.ClientOption{
 			hcloud.WithToken(token),
 			hcloud.WithApplication("machine-controller-manager-provider-hcloud", "v0.0.0"),
+			hcloud.WithHTTPClient(&http.Client{
+				Transport: &rateLimitedTransport{base: httpTransport, limiter: &rateLimiter{}},
+			}),
 		}
 		if endpoint := os.Getenv("HCLOUD_ENDPOINT"); endpoint != "" {
 			opts = append(opts, hcloud.WithEndpoint(endpoint))
 		}
-		client = hcloud.NewClient(opts...)
+
+		entry = &clientCacheEntry{client: hcloud.NewClient(opts...)}
+		singletons[key] = entry
 	}
 
-	return client
+	entry.lastUsed = now
+
+	return entry.client
 }
 
 // SetClientForToken sets a preconfigured HCloud client for the given token.
@@ -60,9 +153,14 @@ func GetClientForToken(token string) *hcloud.Client {
 // token  string         Token to look up client instance for
 // client *hcloud.Client Preconfigured HCloud client
 func SetClientForToken(token string, client *hcloud.Client) {
+	key := getClientCacheKey(token)
+
+	singletonsMutex.Lock()
+	defer singletonsMutex.Unlock()
+
 	if client == nil {
-		delete(singletons, token)
+		delete(singletons, key)
 	} else {
-		singletons[token] = client
+		singletons[key] = &clientCacheEntry{client: client, lastUsed: time.Now(), pinned: true}
 	}
 }
@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	AfterEach(func() {
+		SetClientForToken("token-a", nil)
+		SetClientForToken("token-b", nil)
+	})
+
+	Describe("#GetClientForToken", func() {
+		It("should reuse the client for the same token", func() {
+			client := GetClientForToken("token-a")
+
+			Expect(GetClientForToken("token-a")).To(BeIdenticalTo(client))
+			Expect(GetClientForToken("token-a\n")).To(BeIdenticalTo(client))
+			Expect(GetClientForToken("token-b")).NotTo(BeIdenticalTo(client))
+		})
+
+		It("should return a single client for concurrent callers", func() {
+			var wg sync.WaitGroup
+			clients := make([]*hcloud.Client, 16)
+
+			for index := range clients {
+				wg.Add(1)
+				go func(index int) {
+					defer wg.Done()
+					clients[index] = GetClientForToken("token-a")
+				}(index)
+			}
+			wg.Wait()
+
+			for _, client := range clients {
+				Expect(client).To(BeIdenticalTo(clients[0]))
+			}
+		})
+
+		It("should evict clients unused for the TTL", func() {
+			client := GetClientForToken("token-a")
+
+			singletonsMutex.Lock()
+			singletons[getClientCacheKey("token-a")].lastUsed = time.Now().Add(-2 * ClientCacheTTL)
+			singletonsMutex.Unlock()
+
+			Expect(GetClientForToken("token-a")).NotTo(BeIdenticalTo(client))
+		})
+
+		It("should not evict preconfigured clients", func() {
+			client := hcloud.NewClient()
+			SetClientForToken("token-a", client)
+
+			singletonsMutex.Lock()
+			singletons[getClientCacheKey("token-a")].lastUsed = time.Now().Add(-2 * ClientCacheTTL)
+			singletonsMutex.Unlock()
+
+			Expect(GetClientForToken("token-a")).To(BeIdenticalTo(client))
+		})
+	})
+})
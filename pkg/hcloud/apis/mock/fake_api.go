@@ -0,0 +1,2220 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides all methods required to simulate a driver
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultFakeActionDuration is the default time an action of the fake API takes to complete
+const DefaultFakeActionDuration = 100 * time.Millisecond
+
+const (
+	fakeDefaultPerPage = 25
+	fakeMaxPerPage     = 50
+)
+
+// fakeAction is an action of the fake API together with its completion state
+type fakeAction struct {
+	action     schema.Action
+	completeAt time.Time
+	onComplete func()
+	fault      ActionFault
+}
+
+// FakeRequest is a request received by the fake API
+type FakeRequest struct {
+	Method string
+	Path   string
+	Body   []byte
+}
+
+// FakeAPI is a stateful in-memory fake of the HCloud API. Servers, floating IPs, primary IPs, volumes, networks,
+// firewalls, SSH keys, images, placement groups and actions are kept in memory. List endpoints honour label selectors and pagination
+// and actions complete after ActionDuration.
+type FakeAPI struct {
+	// ActionDuration is the time actions take to complete
+	ActionDuration time.Duration
+	// ActionFaults contains scripted failures of actions by their command, e.g. "create_server"
+	ActionFaults map[string]ActionFault
+	// UnavailableServerTypes contains the server types without capacity left by datacenter name
+	UnavailableServerTypes map[string][]string
+	// ShutdownDuration is the time the operating system of servers takes to shut down after the shutdown action
+	// completed. Servers ignore shutdown requests if it is negative.
+	ShutdownDuration time.Duration
+
+	mutex           sync.Mutex
+	lastID          int
+	requests        []FakeRequest
+	actions         map[int]*fakeAction
+	datacenters     map[int]*schema.Datacenter
+	firewalls       map[int]*schema.Firewall
+	floatingIPs     map[int]*schema.FloatingIP
+	images          map[int]*schema.Image
+	networks        map[int]*schema.Network
+	placementGroups map[int]*schema.PlacementGroup
+	primaryIPs      map[int]*schema.PrimaryIP
+	servers         map[int]*schema.Server
+	serverSSHKeys   map[int][]int
+	shutdowns       map[int]time.Time
+	serverTypes     map[int]*schema.ServerType
+	sshKeys         map[int]*schema.SSHKey
+	volumes         map[int]*schema.Volume
+}
+
+// NewFakeAPI returns a new and empty fake HCloud API.
+func NewFakeAPI() *FakeAPI {
+	return &FakeAPI{
+		ActionDuration:         DefaultFakeActionDuration,
+		ActionFaults:           make(map[string]ActionFault),
+		UnavailableServerTypes: make(map[string][]string),
+		actions:                make(map[int]*fakeAction),
+		datacenters:            make(map[int]*schema.Datacenter),
+		firewalls:              make(map[int]*schema.Firewall),
+		floatingIPs:            make(map[int]*schema.FloatingIP),
+		images:                 make(map[int]*schema.Image),
+		networks:               make(map[int]*schema.Network),
+		placementGroups:        make(map[int]*schema.PlacementGroup),
+		primaryIPs:             make(map[int]*schema.PrimaryIP),
+		servers:                make(map[int]*schema.Server),
+		serverSSHKeys:          make(map[int][]int),
+		shutdowns:              make(map[int]time.Time),
+		serverTypes:            make(map[int]*schema.ServerType),
+		sshKeys:                make(map[int]*schema.SSHKey),
+		volumes:                make(map[int]*schema.Volume),
+	}
+}
+
+// SetupOnMux configures the fake API to handle all requests not handled by more specific handlers of the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func (api *FakeAPI) SetupOnMux(mux *http.ServeMux) {
+	mux.Handle("/", api)
+}
+
+// nextID returns the next unused resource ID. The mutex must be held by the caller.
+//
+// PARAMETERS
+// id int Requested ID or 0 to allocate one
+func (api *FakeAPI) nextID(id int) int {
+	if id == 0 {
+		id = api.lastID + 1
+	}
+	if id > api.lastID {
+		api.lastID = id
+	}
+
+	return id
+}
+
+// AddDatacenter adds the datacenter given to the fake API and returns its ID.
+//
+// PARAMETERS
+// datacenter schema.Datacenter Datacenter to add
+func (api *FakeAPI) AddDatacenter(datacenter schema.Datacenter) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	datacenter.ID = api.nextID(datacenter.ID)
+	api.datacenters[datacenter.ID] = &datacenter
+
+	return datacenter.ID
+}
+
+// AddFirewall adds the firewall given to the fake API and returns its ID.
+//
+// PARAMETERS
+// firewall schema.Firewall Firewall to add
+func (api *FakeAPI) AddFirewall(firewall schema.Firewall) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	firewall.ID = api.nextID(firewall.ID)
+	api.firewalls[firewall.ID] = &firewall
+
+	return firewall.ID
+}
+
+// AddFloatingIP adds the floating IP given to the fake API and returns its ID.
+//
+// PARAMETERS
+// floatingIP schema.FloatingIP Floating IP to add
+func (api *FakeAPI) AddFloatingIP(floatingIP schema.FloatingIP) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	floatingIP.ID = api.nextID(floatingIP.ID)
+	api.floatingIPs[floatingIP.ID] = &floatingIP
+
+	return floatingIP.ID
+}
+
+// AddImage adds the image given to the fake API and returns its ID.
+//
+// PARAMETERS
+// image schema.Image Image to add
+func (api *FakeAPI) AddImage(image schema.Image) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	image.ID = api.nextID(image.ID)
+	if image.Status == "" {
+		image.Status = "available"
+	}
+	api.images[image.ID] = &image
+
+	return image.ID
+}
+
+// AddNetwork adds the network given to the fake API and returns its ID.
+//
+// PARAMETERS
+// network schema.Network Network to add
+func (api *FakeAPI) AddNetwork(network schema.Network) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	network.ID = api.nextID(network.ID)
+	api.networks[network.ID] = &network
+
+	return network.ID
+}
+
+// AddPlacementGroup adds the placement group given to the fake API and returns its ID.
+//
+// PARAMETERS
+// placementGroup schema.PlacementGroup Placement group to add
+func (api *FakeAPI) AddPlacementGroup(placementGroup schema.PlacementGroup) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	placementGroup.ID = api.nextID(placementGroup.ID)
+	api.placementGroups[placementGroup.ID] = &placementGroup
+
+	return placementGroup.ID
+}
+
+// AddPrimaryIP adds the primary IP given to the fake API and returns its ID.
+//
+// PARAMETERS
+// primaryIP schema.PrimaryIP Primary IP to add
+func (api *FakeAPI) AddPrimaryIP(primaryIP schema.PrimaryIP) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	primaryIP.ID = api.nextID(primaryIP.ID)
+	api.primaryIPs[primaryIP.ID] = &primaryIP
+
+	return primaryIP.ID
+}
+
+// AddServer adds the server given to the fake API and returns its ID.
+//
+// PARAMETERS
+// server schema.Server Server to add
+func (api *FakeAPI) AddServer(server schema.Server) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	server.ID = api.nextID(server.ID)
+	api.servers[server.ID] = &server
+
+	return server.ID
+}
+
+// AddServerType adds the server type given to the fake API and returns its ID.
+//
+// PARAMETERS
+// serverType schema.ServerType Server type to add
+func (api *FakeAPI) AddServerType(serverType schema.ServerType) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	serverType.ID = api.nextID(serverType.ID)
+	api.serverTypes[serverType.ID] = &serverType
+
+	return serverType.ID
+}
+
+// AddSSHKey adds the SSH key given to the fake API and returns its ID.
+//
+// PARAMETERS
+// sshKey schema.SSHKey SSH key to add
+func (api *FakeAPI) AddSSHKey(sshKey schema.SSHKey) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	sshKey.ID = api.nextID(sshKey.ID)
+	api.sshKeys[sshKey.ID] = &sshKey
+
+	return sshKey.ID
+}
+
+// AddVolume adds the volume given to the fake API and returns its ID.
+//
+// PARAMETERS
+// volume schema.Volume Volume to add
+func (api *FakeAPI) AddVolume(volume schema.Volume) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	volume.ID = api.nextID(volume.ID)
+	api.volumes[volume.ID] = &volume
+
+	return volume.ID
+}
+
+// Actions returns all actions of the fake API.
+func (api *FakeAPI) Actions() []schema.Action {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.progressActions(time.Now())
+
+	actions := []schema.Action{}
+	for _, id := range sortedIDs(api.actions) {
+		actions = append(actions, api.actions[id].action)
+	}
+
+	return actions
+}
+
+// Requests returns all requests received by the fake API with the method and path given in the order received.
+//
+// PARAMETERS
+// method string HTTP method
+// path   string Request path
+func (api *FakeAPI) Requests(method, path string) []FakeRequest {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	requests := []FakeRequest{}
+	for _, request := range api.requests {
+		if request.Method == method && request.Path == path {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+// Servers returns all servers of the fake API.
+func (api *FakeAPI) Servers() []schema.Server {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.progressActions(time.Now())
+
+	servers := []schema.Server{}
+	for _, id := range sortedIDs(api.servers) {
+		servers = append(servers, api.renderServer(api.servers[id]))
+	}
+
+	return servers
+}
+
+// ServerSSHKeys returns the IDs of the SSH keys the server with the ID given has been created with.
+//
+// PARAMETERS
+// serverID int Server ID
+func (api *FakeAPI) ServerSSHKeys(serverID int) []int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	return append([]int{}, api.serverSSHKeys[serverID]...)
+}
+
+// FloatingIPs returns all floating IPs of the fake API.
+func (api *FakeAPI) FloatingIPs() []schema.FloatingIP {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	floatingIPs := []schema.FloatingIP{}
+	for _, id := range sortedIDs(api.floatingIPs) {
+		floatingIPs = append(floatingIPs, *api.floatingIPs[id])
+	}
+
+	return floatingIPs
+}
+
+// PrimaryIPs returns all primary IPs of the fake API.
+func (api *FakeAPI) PrimaryIPs() []schema.PrimaryIP {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	primaryIPs := []schema.PrimaryIP{}
+	for _, id := range sortedIDs(api.primaryIPs) {
+		primaryIPs = append(primaryIPs, *api.primaryIPs[id])
+	}
+
+	return primaryIPs
+}
+
+// Volumes returns all volumes of the fake API.
+func (api *FakeAPI) Volumes() []schema.Volume {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	volumes := []schema.Volume{}
+	for _, id := range sortedIDs(api.volumes) {
+		volumes = append(volumes, *api.volumes[id])
+	}
+
+	return volumes
+}
+
+// PlacementGroups returns all placement groups of the fake API.
+func (api *FakeAPI) PlacementGroups() []schema.PlacementGroup {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	placementGroups := []schema.PlacementGroup{}
+	for _, id := range sortedIDs(api.placementGroups) {
+		placementGroups = append(placementGroups, api.renderPlacementGroup(api.placementGroups[id]))
+	}
+
+	return placementGroups
+}
+
+// ServeHTTP handles the HCloud API request given.
+//
+// PARAMETERS
+// res http.ResponseWriter HTTP response writer
+// req *http.Request       HTTP request
+func (api *FakeAPI) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.progressActions(time.Now())
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeFakeError(res, http.StatusBadRequest, "invalid_input", "failed to read request body")
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	api.requests = append(api.requests, FakeRequest{Method: req.Method, Path: req.URL.Path, Body: body})
+
+	path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	switch path[0] {
+	case "actions":
+		api.serveActions(res, req, path[1:])
+	case "datacenters":
+		api.serveDatacenters(res, req, path[1:])
+	case "firewalls":
+		api.serveFirewalls(res, req, path[1:])
+	case "floating_ips":
+		api.serveFloatingIPs(res, req, path[1:])
+	case "images":
+		api.serveImages(res, req, path[1:])
+	case "networks":
+		api.serveNetworks(res, req, path[1:])
+	case "placement_groups":
+		api.servePlacementGroups(res, req, path[1:])
+	case "primary_ips":
+		api.servePrimaryIPs(res, req, path[1:])
+	case "servers":
+		api.serveServers(res, req, path[1:])
+	case "server_types":
+		api.serveServerTypes(res, req, path[1:])
+	case "ssh_keys":
+		api.serveSSHKeys(res, req, path[1:])
+	case "volumes":
+		api.serveVolumes(res, req, path[1:])
+	default:
+		writeFakeError(res, http.StatusNotFound, "not_found", fmt.Sprintf("%s not found", req.URL.Path))
+	}
+}
+
+// newAction creates a new running action for the resources given. The mutex must be held by the caller.
+//
+// PARAMETERS
+// command      string       Action command
+// resourceType string       Type of the resource affected
+// resourceIDs  []int        IDs of the resources affected
+// onComplete   func()       Function called once the action completed
+func (api *FakeAPI) newAction(command, resourceType string, resourceIDs []int, onComplete func()) *schema.Action {
+	now := time.Now()
+
+	action := &fakeAction{
+		action: schema.Action{
+			ID:        api.nextID(0),
+			Status:    "running",
+			Command:   command,
+			Started:   now,
+			Resources: []schema.ActionResourceReference{},
+		},
+		completeAt: now.Add(api.ActionDuration),
+		onComplete: onComplete,
+		fault:      api.ActionFaults[command],
+	}
+
+	for _, id := range resourceIDs {
+		action.action.Resources = append(action.action.Resources, schema.ActionResourceReference{ID: id, Type: resourceType})
+	}
+
+	api.actions[action.action.ID] = action
+	api.progressActions(now)
+
+	actionCopy := action.action
+	return &actionCopy
+}
+
+// progressActions updates the progress of all running actions and completes them if due. The mutex must be held by
+// the caller.
+//
+// PARAMETERS
+// now time.Time Current time
+func (api *FakeAPI) progressActions(now time.Time) {
+	for _, id := range sortedIDs(api.actions) {
+		action := api.actions[id]
+
+		if action.action.Status != "running" || action.fault.Stuck {
+			continue
+		}
+
+		if !now.Before(action.completeAt) {
+			finished := action.completeAt
+
+			action.action.Progress = 100
+			action.action.Finished = &finished
+
+			if action.fault.Code != "" {
+				action.action.Status = "error"
+				action.action.Error = &schema.ActionError{Code: action.fault.Code, Message: action.fault.Message}
+			} else {
+				action.action.Status = "success"
+
+				if action.onComplete != nil {
+					action.onComplete()
+				}
+			}
+		} else if duration := action.completeAt.Sub(action.action.Started); duration > 0 {
+			action.action.Progress = int(100 * now.Sub(action.action.Started) / duration)
+		}
+	}
+
+	for id, shutdownAt := range api.shutdowns {
+		if now.Before(shutdownAt) {
+			continue
+		}
+
+		if server, ok := api.servers[id]; ok {
+			server.Status = "off"
+		}
+
+		delete(api.shutdowns, id)
+	}
+}
+
+// serveActions handles requests of the "/actions" endpoint.
+func (api *FakeAPI) serveActions(res http.ResponseWriter, req *http.Request, path []string) {
+	if req.Method != http.MethodGet {
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	if len(path) == 1 {
+		action, ok := api.actions[parseFakeID(path[0])]
+		if !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "action not found")
+			return
+		}
+
+		writeFakeJSON(res, http.StatusOK, schema.ActionGetResponse{Action: action.action})
+		return
+	}
+
+	query := req.URL.Query()
+	actionIDs := query["id"]
+
+	api.listActions(res, req, func(action *fakeAction) bool {
+		if len(actionIDs) == 0 {
+			return true
+		}
+
+		for _, id := range actionIDs {
+			if parseFakeID(id) == action.action.ID {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// listActions writes all actions matching the filter given and the status requested.
+func (api *FakeAPI) listActions(res http.ResponseWriter, req *http.Request, filter func(*fakeAction) bool) {
+	statuses := req.URL.Query()["status"]
+
+	actions := []schema.Action{}
+	for _, id := range sortedIDs(api.actions) {
+		action := api.actions[id]
+
+		if !filter(action) || (len(statuses) > 0 && !containsString(statuses, action.action.Status)) {
+			continue
+		}
+
+		actions = append(actions, action.action)
+	}
+
+	page, meta, ok := paginate(res, req, actions)
+	if !ok {
+		return
+	}
+
+	writeFakeJSON(res, http.StatusOK, struct {
+		schema.ActionListResponse
+		schema.MetaResponse
+	}{schema.ActionListResponse{Actions: page}, meta})
+}
+
+// listResourceActions writes all actions of the resource given.
+func (api *FakeAPI) listResourceActions(res http.ResponseWriter, req *http.Request, resourceType string, resourceID int) {
+	api.listActions(res, req, func(action *fakeAction) bool {
+		for _, resource := range action.action.Resources {
+			if resource.Type == resourceType && resource.ID == resourceID {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// serveDatacenters handles requests of the "/datacenters" endpoint.
+func (api *FakeAPI) serveDatacenters(res http.ResponseWriter, req *http.Request, path []string) {
+	if req.Method != http.MethodGet {
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	if len(path) == 1 {
+		datacenter, ok := api.datacenters[parseFakeID(path[0])]
+		if !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "datacenter not found")
+			return
+		}
+
+		writeFakeJSON(res, http.StatusOK, schema.DatacenterGetResponse{Datacenter: *datacenter})
+		return
+	}
+
+	name := req.URL.Query().Get("name")
+
+	datacenters := []schema.Datacenter{}
+	for _, id := range sortedIDs(api.datacenters) {
+		if datacenter := api.datacenters[id]; name == "" || name == datacenter.Name {
+			datacenters = append(datacenters, *datacenter)
+		}
+	}
+
+	page, meta, ok := paginate(res, req, datacenters)
+	if !ok {
+		return
+	}
+
+	writeFakeJSON(res, http.StatusOK, struct {
+		schema.DatacenterListResponse
+		schema.MetaResponse
+	}{schema.DatacenterListResponse{Datacenters: page}, meta})
+}
+
+// serveFirewalls handles requests of the "/firewalls" endpoint.
+func (api *FakeAPI) serveFirewalls(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		if req.Method != http.MethodGet {
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+			return
+		}
+
+		query := req.URL.Query()
+
+		selector, ok := parseFakeLabelSelector(res, query)
+		if !ok {
+			return
+		}
+
+		firewalls := []schema.Firewall{}
+		for _, id := range sortedIDs(api.firewalls) {
+			firewall := api.firewalls[id]
+
+			if query.Get("name") != "" && query.Get("name") != firewall.Name {
+				continue
+			}
+			if !selector.Matches(labels.Set(firewall.Labels)) {
+				continue
+			}
+
+			firewalls = append(firewalls, *firewall)
+		}
+
+		page, meta, ok := paginate(res, req, firewalls)
+		if !ok {
+			return
+		}
+
+		writeFakeJSON(res, http.StatusOK, struct {
+			schema.FirewallListResponse
+			schema.MetaResponse
+		}{schema.FirewallListResponse{Firewalls: page}, meta})
+
+		return
+	}
+
+	firewall, ok := api.firewalls[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "firewall not found")
+		return
+	}
+
+	if req.Method != http.MethodGet {
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	writeFakeJSON(res, http.StatusOK, schema.FirewallGetResponse{Firewall: *firewall})
+}
+
+// serveFloatingIPs handles requests of the "/floating_ips" endpoint.
+func (api *FakeAPI) serveFloatingIPs(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			query := req.URL.Query()
+
+			selector, ok := parseFakeLabelSelector(res, query)
+			if !ok {
+				return
+			}
+
+			floatingIPs := []schema.FloatingIP{}
+			for _, id := range sortedIDs(api.floatingIPs) {
+				floatingIP := api.floatingIPs[id]
+
+				if (query.Get("name") == "" || query.Get("name") == floatingIP.Name) && selector.Matches(labels.Set(floatingIP.Labels)) {
+					floatingIPs = append(floatingIPs, *floatingIP)
+				}
+			}
+
+			page, meta, ok := paginate(res, req, floatingIPs)
+			if !ok {
+				return
+			}
+
+			writeFakeJSON(res, http.StatusOK, struct {
+				schema.FloatingIPListResponse
+				schema.MetaResponse
+			}{schema.FloatingIPListResponse{FloatingIPs: page}, meta})
+		case http.MethodPost:
+			var body schema.FloatingIPCreateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			if body.Name != nil && api.findFloatingIPByName(*body.Name) != nil {
+				writeFakeError(res, http.StatusConflict, "uniqueness_error", "name is already used")
+				return
+			}
+
+			floatingIP := &schema.FloatingIP{
+				ID:          api.nextID(0),
+				Description: body.Description,
+				Created:     time.Now(),
+				Type:        body.Type,
+				Labels:      map[string]string{},
+				DNSPtr:      []schema.FloatingIPDNSPtr{},
+			}
+			if body.Name != nil {
+				floatingIP.Name = *body.Name
+			}
+			if body.Labels != nil {
+				floatingIP.Labels = *body.Labels
+			}
+			if body.HomeLocation != nil {
+				floatingIP.HomeLocation = schema.Location{Name: *body.HomeLocation}
+			}
+
+			if floatingIP.Type == "ipv6" {
+				floatingIP.IP = fmt.Sprintf("2001:db8:%x::/64", floatingIP.ID)
+			} else {
+				floatingIP.IP = fmt.Sprintf("198.51.100.%d", floatingIP.ID%256)
+			}
+
+			var action *schema.Action
+
+			if body.Server != nil {
+				server, ok := api.servers[*body.Server]
+				if !ok {
+					writeFakeError(res, http.StatusNotFound, "not_found", "server not found")
+					return
+				}
+
+				serverID := server.ID
+				floatingIP.Server = &serverID
+				floatingIP.HomeLocation = server.Datacenter.Location
+
+				action = api.newAction("assign_floating_ip", "floating_ip", []int{floatingIP.ID}, nil)
+			}
+
+			api.floatingIPs[floatingIP.ID] = floatingIP
+
+			writeFakeJSON(res, http.StatusCreated, schema.FloatingIPCreateResponse{FloatingIP: *floatingIP, Action: action})
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	floatingIP, ok := api.floatingIPs[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "floating IP not found")
+		return
+	}
+
+	if len(path) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			writeFakeJSON(res, http.StatusOK, schema.FloatingIPGetResponse{FloatingIP: *floatingIP})
+		case http.MethodPut:
+			var body schema.FloatingIPUpdateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			if body.Name != "" {
+				floatingIP.Name = body.Name
+			}
+			if body.Labels != nil {
+				floatingIP.Labels = *body.Labels
+			}
+
+			writeFakeJSON(res, http.StatusOK, schema.FloatingIPUpdateResponse{FloatingIP: *floatingIP})
+		case http.MethodDelete:
+			delete(api.floatingIPs, floatingIP.ID)
+			writeFakeNoContent(res)
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	if path[1] != "actions" {
+		writeFakeError(res, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+
+	if len(path) == 2 {
+		api.listResourceActions(res, req, "floating_ip", floatingIP.ID)
+		return
+	}
+
+	switch path[2] {
+	case "assign":
+		var body schema.FloatingIPActionAssignRequest
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		if _, ok := api.servers[body.Server]; !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "server not found")
+			return
+		}
+
+		serverID := body.Server
+		floatingIP.Server = &serverID
+
+		writeFakeJSON(res, http.StatusCreated, schema.FloatingIPActionAssignResponse{
+			Action: *api.newAction("assign_floating_ip", "floating_ip", []int{floatingIP.ID}, nil),
+		})
+	case "unassign":
+		floatingIP.Server = nil
+
+		writeFakeJSON(res, http.StatusCreated, schema.FloatingIPActionUnassignResponse{
+			Action: *api.newAction("unassign_floating_ip", "floating_ip", []int{floatingIP.ID}, nil),
+		})
+	default:
+		writeFakeError(res, http.StatusNotFound, "not_found", "action not supported")
+	}
+}
+
+// findFloatingIPByName returns the floating IP with the name given or nil. The mutex must be held by the caller.
+func (api *FakeAPI) findFloatingIPByName(name string) *schema.FloatingIP {
+	for _, floatingIP := range api.floatingIPs {
+		if floatingIP.Name == name {
+			return floatingIP
+		}
+	}
+
+	return nil
+}
+
+// serveImages handles requests of the "/images" endpoint.
+func (api *FakeAPI) serveImages(res http.ResponseWriter, req *http.Request, path []string) {
+	if req.Method != http.MethodGet {
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	if len(path) == 1 {
+		image, ok := api.images[parseFakeID(path[0])]
+		if !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "image not found")
+			return
+		}
+
+		writeFakeJSON(res, http.StatusOK, schema.ImageGetResponse{Image: *image})
+		return
+	}
+
+	query := req.URL.Query()
+
+	selector, ok := parseFakeLabelSelector(res, query)
+	if !ok {
+		return
+	}
+
+	images := []schema.Image{}
+	for _, id := range sortedIDs(api.images) {
+		image := api.images[id]
+
+		if query.Get("name") != "" && (image.Name == nil || *image.Name != query.Get("name")) {
+			continue
+		}
+		if len(query["type"]) > 0 && !containsString(query["type"], image.Type) {
+			continue
+		}
+		if len(query["status"]) > 0 && !containsString(query["status"], image.Status) {
+			continue
+		}
+		if len(query["architecture"]) > 0 && !containsString(query["architecture"], image.Architecture) {
+			continue
+		}
+		if !selector.Matches(labels.Set(image.Labels)) {
+			continue
+		}
+
+		images = append(images, *image)
+	}
+
+	page, meta, ok := paginate(res, req, images)
+	if !ok {
+		return
+	}
+
+	writeFakeJSON(res, http.StatusOK, struct {
+		schema.ImageListResponse
+		schema.MetaResponse
+	}{schema.ImageListResponse{Images: page}, meta})
+}
+
+// serveNetworks handles requests of the "/networks" endpoint.
+func (api *FakeAPI) serveNetworks(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			query := req.URL.Query()
+
+			selector, ok := parseFakeLabelSelector(res, query)
+			if !ok {
+				return
+			}
+
+			networks := []schema.Network{}
+			for _, id := range sortedIDs(api.networks) {
+				network := api.networks[id]
+
+				if (query.Get("name") == "" || query.Get("name") == network.Name) && selector.Matches(labels.Set(network.Labels)) {
+					networks = append(networks, api.renderNetwork(network))
+				}
+			}
+
+			page, meta, ok := paginate(res, req, networks)
+			if !ok {
+				return
+			}
+
+			writeFakeJSON(res, http.StatusOK, struct {
+				schema.NetworkListResponse
+				schema.MetaResponse
+			}{schema.NetworkListResponse{Networks: page}, meta})
+		case http.MethodPost:
+			var body schema.NetworkCreateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			if _, err := netip.ParsePrefix(body.IPRange); err != nil {
+				writeFakeError(res, http.StatusBadRequest, "invalid_input", "invalid ip_range")
+				return
+			}
+
+			network := &schema.Network{
+				ID:      api.nextID(0),
+				Name:    body.Name,
+				Created: time.Now(),
+				IPRange: body.IPRange,
+				Subnets: body.Subnets,
+				Routes:  body.Routes,
+				Labels:  map[string]string{},
+			}
+			if body.Labels != nil {
+				network.Labels = *body.Labels
+			}
+
+			api.networks[network.ID] = network
+
+			writeFakeJSON(res, http.StatusCreated, schema.NetworkCreateResponse{Network: api.renderNetwork(network)})
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	network, ok := api.networks[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "network not found")
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeFakeJSON(res, http.StatusOK, schema.NetworkGetResponse{Network: api.renderNetwork(network)})
+	case http.MethodDelete:
+		delete(api.networks, network.ID)
+		writeFakeNoContent(res)
+	default:
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+// renderNetwork returns the API representation of the network given. The mutex must be held by the caller.
+func (api *FakeAPI) renderNetwork(network *schema.Network) schema.Network {
+	rendered := *network
+	rendered.Servers = []int{}
+
+	for _, id := range sortedIDs(api.servers) {
+		for _, privateNet := range api.servers[id].PrivateNet {
+			if privateNet.Network == network.ID {
+				rendered.Servers = append(rendered.Servers, id)
+			}
+		}
+	}
+
+	return rendered
+}
+
+// allocateNetworkIP returns the first unused IP of the IP range given in the network. The mutex must be held by the
+// caller.
+//
+// PARAMETERS
+// network *schema.Network Network to allocate IP in
+// ipRange string          IP range to allocate IP from or an empty string for the full network range
+func (api *FakeAPI) allocateNetworkIP(network *schema.Network, ipRange string) (string, bool) {
+	if ipRange == "" {
+		ipRange = network.IPRange
+	}
+
+	prefix, err := netip.ParsePrefix(ipRange)
+	if err != nil {
+		return "", false
+	}
+
+	used := map[string]bool{}
+	for _, server := range api.servers {
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network == network.ID {
+				used[privateNet.IP] = true
+			}
+		}
+	}
+
+	// The first address is reserved for the network and the second one for the gateway
+	for ip := prefix.Masked().Addr().Next().Next(); prefix.Contains(ip); ip = ip.Next() {
+		if !used[ip.String()] {
+			return ip.String(), true
+		}
+	}
+
+	return "", false
+}
+
+// servePlacementGroups handles requests of the "/placement_groups" endpoint.
+func (api *FakeAPI) servePlacementGroups(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			query := req.URL.Query()
+
+			selector, ok := parseFakeLabelSelector(res, query)
+			if !ok {
+				return
+			}
+
+			placementGroups := []schema.PlacementGroup{}
+			for _, id := range sortedIDs(api.placementGroups) {
+				placementGroup := api.placementGroups[id]
+
+				if query.Get("name") != "" && query.Get("name") != placementGroup.Name {
+					continue
+				}
+				if len(query["type"]) > 0 && !containsString(query["type"], placementGroup.Type) {
+					continue
+				}
+				if !selector.Matches(labels.Set(placementGroup.Labels)) {
+					continue
+				}
+
+				placementGroups = append(placementGroups, api.renderPlacementGroup(placementGroup))
+			}
+
+			page, meta, ok := paginate(res, req, placementGroups)
+			if !ok {
+				return
+			}
+
+			writeFakeJSON(res, http.StatusOK, struct {
+				schema.PlacementGroupListResponse
+				schema.MetaResponse
+			}{schema.PlacementGroupListResponse{PlacementGroups: page}, meta})
+		case http.MethodPost:
+			var body schema.PlacementGroupCreateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			for _, placementGroup := range api.placementGroups {
+				if placementGroup.Name == body.Name {
+					writeFakeError(res, http.StatusConflict, "uniqueness_error", "name is already used")
+					return
+				}
+			}
+
+			placementGroup := &schema.PlacementGroup{
+				ID:      api.nextID(0),
+				Name:    body.Name,
+				Created: time.Now(),
+				Type:    body.Type,
+				Labels:  map[string]string{},
+			}
+			if body.Labels != nil {
+				placementGroup.Labels = *body.Labels
+			}
+
+			api.placementGroups[placementGroup.ID] = placementGroup
+
+			writeFakeJSON(res, http.StatusCreated, schema.PlacementGroupCreateResponse{PlacementGroup: api.renderPlacementGroup(placementGroup)})
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	placementGroup, ok := api.placementGroups[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "placement group not found")
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeFakeJSON(res, http.StatusOK, schema.PlacementGroupGetResponse{PlacementGroup: api.renderPlacementGroup(placementGroup)})
+	case http.MethodDelete:
+		for _, server := range api.servers {
+			if server.PlacementGroup != nil && server.PlacementGroup.ID == placementGroup.ID {
+				writeFakeError(res, http.StatusUnprocessableEntity, "resource_in_use", "placement group is in use")
+				return
+			}
+		}
+
+		delete(api.placementGroups, placementGroup.ID)
+		writeFakeNoContent(res)
+	default:
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+// renderPlacementGroup returns the API representation of the placement group given. The mutex must be held by the
+// caller.
+func (api *FakeAPI) renderPlacementGroup(placementGroup *schema.PlacementGroup) schema.PlacementGroup {
+	rendered := *placementGroup
+	rendered.Servers = []int{}
+
+	for _, id := range sortedIDs(api.servers) {
+		if server := api.servers[id]; server.PlacementGroup != nil && server.PlacementGroup.ID == placementGroup.ID {
+			rendered.Servers = append(rendered.Servers, id)
+		}
+	}
+
+	return rendered
+}
+
+// servePrimaryIPs handles requests of the "/primary_ips" endpoint.
+func (api *FakeAPI) servePrimaryIPs(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		if req.Method != http.MethodGet {
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+			return
+		}
+
+		query := req.URL.Query()
+
+		selector, ok := parseFakeLabelSelector(res, query)
+		if !ok {
+			return
+		}
+
+		primaryIPs := []schema.PrimaryIP{}
+		for _, id := range sortedIDs(api.primaryIPs) {
+			primaryIP := api.primaryIPs[id]
+
+			if (query.Get("name") == "" || query.Get("name") == primaryIP.Name) && selector.Matches(labels.Set(primaryIP.Labels)) {
+				primaryIPs = append(primaryIPs, *primaryIP)
+			}
+		}
+
+		page, meta, ok := paginate(res, req, primaryIPs)
+		if !ok {
+			return
+		}
+
+		writeFakeJSON(res, http.StatusOK, struct {
+			schema.PrimaryIPListResult
+			schema.MetaResponse
+		}{schema.PrimaryIPListResult{PrimaryIPs: page}, meta})
+
+		return
+	}
+
+	primaryIP, ok := api.primaryIPs[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "primary IP not found")
+		return
+	}
+
+	if len(path) > 1 {
+		writeFakeError(res, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeFakeJSON(res, http.StatusOK, schema.PrimaryIPGetResult{PrimaryIP: *primaryIP})
+	case http.MethodPut:
+		var body struct {
+			AutoDelete *bool              `json:"auto_delete"`
+			Labels     *map[string]string `json:"labels"`
+			Name       string             `json:"name"`
+		}
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		if body.AutoDelete != nil {
+			primaryIP.AutoDelete = *body.AutoDelete
+		}
+		if body.Labels != nil {
+			primaryIP.Labels = *body.Labels
+		}
+		if body.Name != "" {
+			primaryIP.Name = body.Name
+		}
+
+		writeFakeJSON(res, http.StatusOK, schema.PrimaryIPUpdateResult{PrimaryIP: *primaryIP})
+	case http.MethodDelete:
+		if primaryIP.AssigneeID != 0 {
+			writeFakeError(res, http.StatusConflict, "resource_in_use", "primary IP is assigned")
+			return
+		}
+
+		delete(api.primaryIPs, primaryIP.ID)
+		writeFakeNoContent(res)
+	default:
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+// serveServers handles requests of the "/servers" endpoint.
+func (api *FakeAPI) serveServers(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			query := req.URL.Query()
+
+			selector, ok := parseFakeLabelSelector(res, query)
+			if !ok {
+				return
+			}
+
+			servers := []schema.Server{}
+			for _, id := range sortedIDs(api.servers) {
+				server := api.servers[id]
+
+				if query.Get("name") != "" && query.Get("name") != server.Name {
+					continue
+				}
+				if len(query["status"]) > 0 && !containsString(query["status"], server.Status) {
+					continue
+				}
+				if !selector.Matches(labels.Set(server.Labels)) {
+					continue
+				}
+
+				servers = append(servers, api.renderServer(server))
+			}
+
+			page, meta, ok := paginate(res, req, servers)
+			if !ok {
+				return
+			}
+
+			writeFakeJSON(res, http.StatusOK, struct {
+				schema.ServerListResponse
+				schema.MetaResponse
+			}{schema.ServerListResponse{Servers: page}, meta})
+		case http.MethodPost:
+			api.createServer(res, req)
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	server, ok := api.servers[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "server not found")
+		return
+	}
+
+	if len(path) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			writeFakeJSON(res, http.StatusOK, schema.ServerGetResponse{Server: api.renderServer(server)})
+		case http.MethodPut:
+			var body schema.ServerUpdateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			if body.Name != "" {
+				server.Name = body.Name
+			}
+			if body.Labels != nil {
+				server.Labels = *body.Labels
+			}
+
+			writeFakeJSON(res, http.StatusOK, schema.ServerUpdateResponse{Server: api.renderServer(server)})
+		case http.MethodDelete:
+			if server.Protection.Delete {
+				writeFakeError(res, http.StatusForbidden, "protected", "server is protected against deletion")
+				return
+			}
+
+			// Servers are removed once the deletion action completed and remain if it fails
+			action := api.newAction("delete_server", "server", []int{server.ID}, func() {
+				delete(api.servers, server.ID)
+				delete(api.serverSSHKeys, server.ID)
+				delete(api.shutdowns, server.ID)
+
+				for _, floatingIP := range api.floatingIPs {
+					if floatingIP.Server != nil && *floatingIP.Server == server.ID {
+						floatingIP.Server = nil
+					}
+				}
+
+				for _, primaryIP := range api.primaryIPs {
+					if primaryIP.AssigneeID != server.ID {
+						continue
+					}
+
+					if primaryIP.AutoDelete {
+						delete(api.primaryIPs, primaryIP.ID)
+					} else {
+						primaryIP.AssigneeID = 0
+					}
+				}
+
+				for _, volume := range api.volumes {
+					if volume.Server != nil && *volume.Server == server.ID {
+						volume.Server = nil
+					}
+				}
+			})
+
+			writeFakeJSON(res, http.StatusOK, schema.ServerDeleteResponse{Action: *action})
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	if path[1] != "actions" {
+		writeFakeError(res, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+
+	if len(path) == 2 {
+		api.listResourceActions(res, req, "server", server.ID)
+		return
+	}
+
+	api.serveServerAction(res, req, server, path[2])
+}
+
+// createServer handles server creation requests.
+func (api *FakeAPI) createServer(res http.ResponseWriter, req *http.Request) {
+	var body schema.ServerCreateRequest
+	if !decodeFakeBody(res, req, &body) {
+		return
+	}
+
+	if body.Name == "" {
+		writeFakeError(res, http.StatusBadRequest, "invalid_input", "name is required")
+		return
+	}
+
+	for _, server := range api.servers {
+		if server.Name == body.Name {
+			writeFakeError(res, http.StatusConflict, "uniqueness_error", "server name is already used")
+			return
+		}
+	}
+
+	image := api.findImage(body.Image)
+	if image == nil {
+		writeFakeError(res, http.StatusBadRequest, "invalid_input", "image not found")
+		return
+	}
+
+	for _, sshKeyID := range body.SSHKeys {
+		if _, ok := api.sshKeys[sshKeyID]; !ok {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("SSH key %d not found", sshKeyID))
+			return
+		}
+	}
+
+	datacenter := body.Datacenter
+	if datacenter == "" {
+		datacenter = fmt.Sprintf("%s-dc1", body.Location)
+	}
+
+	serverType := fmt.Sprintf("%v", body.ServerType)
+
+	if containsString(api.UnavailableServerTypes[datacenter], serverType) {
+		writeFakeError(res, http.StatusPreconditionFailed, "resource_unavailable", fmt.Sprintf("server type %s is unavailable in %s", serverType, datacenter))
+		return
+	}
+
+	serverTypeData := api.findServerType(serverType, image.Architecture)
+	if serverTypeData.Architecture != image.Architecture {
+		writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("image architecture %s does not match server type %s", image.Architecture, serverType))
+		return
+	}
+
+	server := &schema.Server{
+		ID:         api.nextID(0),
+		Name:       body.Name,
+		Status:     "initializing",
+		Created:    time.Now(),
+		ServerType: serverTypeData,
+		Datacenter: api.findDatacenter(datacenter),
+		Image:      image,
+		Labels:     map[string]string{},
+		PrivateNet: []schema.ServerPrivateNet{},
+		Volumes:    []int{},
+	}
+	if body.Labels != nil {
+		server.Labels = *body.Labels
+	}
+
+	publicNet := body.PublicNet
+	if publicNet == nil {
+		publicNet = &schema.ServerCreatePublicNet{EnableIPv4: true, EnableIPv6: true}
+	}
+
+	var primaryIPs []*schema.PrimaryIP
+
+	if publicNet.EnableIPv4 {
+		primaryIP, ok := api.allocatePrimaryIP(publicNet.IPv4ID, "ipv4", server)
+		if !ok {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("primary IP %d is not available", publicNet.IPv4ID))
+			return
+		}
+
+		primaryIPs = append(primaryIPs, primaryIP)
+		server.PublicNet.IPv4 = schema.ServerPublicNetIPv4{ID: primaryIP.ID, IP: primaryIP.IP}
+	}
+	if publicNet.EnableIPv6 {
+		primaryIP, ok := api.allocatePrimaryIP(publicNet.IPv6ID, "ipv6", server)
+		if !ok {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("primary IP %d is not available", publicNet.IPv6ID))
+			return
+		}
+
+		primaryIPs = append(primaryIPs, primaryIP)
+		server.PublicNet.IPv6 = schema.ServerPublicNetIPv6{ID: primaryIP.ID, IP: primaryIP.IP}
+	}
+
+	for _, firewall := range body.Firewalls {
+		if _, ok := api.firewalls[firewall.Firewall]; !ok {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("firewall %d not found", firewall.Firewall))
+			return
+		}
+
+		server.PublicNet.Firewalls = append(server.PublicNet.Firewalls, schema.ServerFirewall{ID: firewall.Firewall, Status: "applied"})
+	}
+
+	for _, networkID := range body.Networks {
+		network, ok := api.networks[networkID]
+		if !ok {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("network %d not found", networkID))
+			return
+		}
+
+		ip, ok := api.allocateNetworkIP(network, "")
+		if !ok {
+			writeFakeError(res, http.StatusUnprocessableEntity, "ip_not_available", "no IP available")
+			return
+		}
+
+		server.PrivateNet = append(server.PrivateNet, schema.ServerPrivateNet{Network: network.ID, IP: ip, AliasIPs: []string{}})
+	}
+
+	if body.PlacementGroup != 0 {
+		placementGroup, ok := api.placementGroups[body.PlacementGroup]
+		if !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "placement group not found")
+			return
+		}
+
+		server.PlacementGroup = placementGroup
+	}
+
+	startAfterCreate := body.StartAfterCreate == nil || *body.StartAfterCreate
+
+	api.servers[server.ID] = server
+	api.serverSSHKeys[server.ID] = body.SSHKeys
+
+	for _, primaryIP := range primaryIPs {
+		primaryIP.AssigneeID = server.ID
+		primaryIP.AssigneeType = "server"
+		api.primaryIPs[primaryIP.ID] = primaryIP
+	}
+
+	action := api.newAction("create_server", "server", []int{server.ID}, func() {
+		if startAfterCreate {
+			server.Status = "running"
+		} else {
+			server.Status = "off"
+		}
+	})
+
+	response := schema.ServerCreateResponse{
+		Server:      api.renderServer(server),
+		Action:      *action,
+		NextActions: []schema.Action{},
+	}
+
+	if len(body.SSHKeys) == 0 {
+		rootPassword := "test"
+		response.RootPassword = &rootPassword
+	}
+
+	writeFakeJSON(res, http.StatusCreated, response)
+}
+
+// serveServerAction handles server action requests.
+func (api *FakeAPI) serveServerAction(res http.ResponseWriter, req *http.Request, server *schema.Server, command string) {
+	if req.Method != http.MethodPost {
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	var action *schema.Action
+
+	switch command {
+	case "poweron":
+		server.Status = "starting"
+		action = api.newAction("start_server", "server", []int{server.ID}, func() { server.Status = "running" })
+	case "poweroff":
+		delete(api.shutdowns, server.ID)
+
+		server.Status = "stopping"
+		action = api.newAction("poweroff_server", "server", []int{server.ID}, func() { server.Status = "off" })
+	case "shutdown":
+		// The shutdown action completes once the ACPI request has been sent and the operating system shuts down afterwards
+		action = api.newAction("shutdown_server", "server", []int{server.ID}, func() {
+			if api.ShutdownDuration >= 0 {
+				api.shutdowns[server.ID] = time.Now().Add(api.ShutdownDuration)
+			}
+		})
+	case "attach_to_network":
+		var body struct {
+			Network  int      `json:"network"`
+			IP       string   `json:"ip,omitempty"`
+			IPRange  string   `json:"ip_range,omitempty"`
+			AliasIPs []string `json:"alias_ips,omitempty"`
+		}
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		network, ok := api.networks[body.Network]
+		if !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "network not found")
+			return
+		}
+
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network == network.ID {
+				writeFakeError(res, http.StatusUnprocessableEntity, "server_already_attached", "server is already attached to the network")
+				return
+			}
+		}
+
+		ip := body.IP
+		if ip == "" {
+			ip, ok = api.allocateNetworkIP(network, body.IPRange)
+			if !ok {
+				writeFakeError(res, http.StatusUnprocessableEntity, "ip_not_available", "no IP available")
+				return
+			}
+		}
+
+		aliasIPs := body.AliasIPs
+		if aliasIPs == nil {
+			aliasIPs = []string{}
+		}
+
+		server.PrivateNet = append(server.PrivateNet, schema.ServerPrivateNet{Network: network.ID, IP: ip, AliasIPs: aliasIPs})
+		action = api.newAction("attach_to_network", "server", []int{server.ID}, nil)
+	case "detach_from_network":
+		var body schema.ServerActionDetachFromNetworkRequest
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		privateNets := []schema.ServerPrivateNet{}
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network != body.Network {
+				privateNets = append(privateNets, privateNet)
+			}
+		}
+
+		server.PrivateNet = privateNets
+		action = api.newAction("detach_from_network", "server", []int{server.ID}, nil)
+	case "change_alias_ips":
+		var body schema.ServerActionChangeAliasIPsRequest
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		for index := range server.PrivateNet {
+			if server.PrivateNet[index].Network == body.Network {
+				server.PrivateNet[index].AliasIPs = body.AliasIPs
+			}
+		}
+
+		action = api.newAction("change_alias_ips", "server", []int{server.ID}, nil)
+	case "add_to_placement_group":
+		var body schema.ServerActionAddToPlacementGroupRequest
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		placementGroup, ok := api.placementGroups[body.PlacementGroup]
+		if !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "placement group not found")
+			return
+		}
+
+		server.PlacementGroup = placementGroup
+		action = api.newAction("add_to_placement_group", "server", []int{server.ID}, nil)
+	case "remove_from_placement_group":
+		server.PlacementGroup = nil
+		action = api.newAction("remove_from_placement_group", "server", []int{server.ID}, nil)
+	case "change_protection":
+		var body schema.ServerActionChangeProtectionRequest
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		protection := server.Protection
+		if body.Delete != nil {
+			protection.Delete = *body.Delete
+		}
+		if body.Rebuild != nil {
+			protection.Rebuild = *body.Rebuild
+		}
+
+		if protection.Delete != protection.Rebuild {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", "delete and rebuild protection must have the same value")
+			return
+		}
+
+		server.Protection = protection
+		action = api.newAction("change_protection", "server", []int{server.ID}, nil)
+	default:
+		writeFakeError(res, http.StatusNotFound, "not_found", "action not supported")
+		return
+	}
+
+	writeFakeJSON(res, http.StatusCreated, schema.ActionGetResponse{Action: *action})
+}
+
+// findServerType returns the server type with the name given or a minimal one if it is unknown. The mutex must be
+// held by the caller.
+func (api *FakeAPI) findServerType(name, architecture string) schema.ServerType {
+	for _, serverType := range api.serverTypes {
+		if serverType.Name == name {
+			return *serverType
+		}
+	}
+
+	return schema.ServerType{Name: name, Architecture: architecture}
+}
+
+// findDatacenter returns the datacenter with the name given or a minimal one if it is unknown. The mutex must be held
+// by the caller.
+func (api *FakeAPI) findDatacenter(name string) schema.Datacenter {
+	for _, datacenter := range api.datacenters {
+		if datacenter.Name == name {
+			return *datacenter
+		}
+	}
+
+	return schema.Datacenter{
+		Name: name,
+		Location: schema.Location{
+			Name:        strings.SplitN(name, "-", 2)[0],
+			NetworkZone: "eu-central",
+		},
+	}
+}
+
+// findImage returns the image referenced by ID or name. The mutex must be held by the caller.
+func (api *FakeAPI) findImage(reference interface{}) *schema.Image {
+	for _, id := range sortedIDs(api.images) {
+		image := api.images[id]
+
+		switch value := reference.(type) {
+		case float64:
+			if int(value) == image.ID {
+				return image
+			}
+		case string:
+			if image.Name != nil && *image.Name == value {
+				return image
+			}
+		}
+	}
+
+	return nil
+}
+
+// allocatePrimaryIP returns the unassigned primary IP with the ID given or a new one to be deleted with the server
+// given. The mutex must be held by the caller.
+func (api *FakeAPI) allocatePrimaryIP(id int, ipType string, server *schema.Server) (*schema.PrimaryIP, bool) {
+	if id != 0 {
+		primaryIP, ok := api.primaryIPs[id]
+		if !ok || primaryIP.Type != ipType || primaryIP.AssigneeID != 0 {
+			return nil, false
+		}
+
+		return primaryIP, true
+	}
+
+	primaryIP := &schema.PrimaryIP{
+		ID:         api.nextID(0),
+		Type:       ipType,
+		Labels:     map[string]string{},
+		DNSPtr:     []schema.PrimaryIPDNSPTR{},
+		AutoDelete: true,
+		Created:    time.Now(),
+		Datacenter: server.Datacenter,
+	}
+
+	primaryIP.Name = fmt.Sprintf("primary_ip-%d", primaryIP.ID)
+
+	if ipType == "ipv6" {
+		primaryIP.IP = fmt.Sprintf("2001:db8:%x:1::/64", primaryIP.ID)
+	} else {
+		primaryIP.IP = fmt.Sprintf("203.0.113.%d", primaryIP.ID%256)
+	}
+
+	return primaryIP, true
+}
+
+// renderServer returns the API representation of the server given. The mutex must be held by the caller.
+func (api *FakeAPI) renderServer(server *schema.Server) schema.Server {
+	rendered := *server
+	rendered.PublicNet.FloatingIPs = []int{}
+	rendered.Volumes = []int{}
+
+	for _, id := range sortedIDs(api.floatingIPs) {
+		if floatingIP := api.floatingIPs[id]; floatingIP.Server != nil && *floatingIP.Server == server.ID {
+			rendered.PublicNet.FloatingIPs = append(rendered.PublicNet.FloatingIPs, id)
+		}
+	}
+
+	for _, id := range sortedIDs(api.volumes) {
+		if volume := api.volumes[id]; volume.Server != nil && *volume.Server == server.ID {
+			rendered.Volumes = append(rendered.Volumes, id)
+		}
+	}
+
+	if server.PlacementGroup != nil {
+		if placementGroup, ok := api.placementGroups[server.PlacementGroup.ID]; ok {
+			renderedPlacementGroup := api.renderPlacementGroup(placementGroup)
+			rendered.PlacementGroup = &renderedPlacementGroup
+		}
+	}
+
+	return rendered
+}
+
+// serveServerTypes handles requests of the "/server_types" endpoint.
+func (api *FakeAPI) serveServerTypes(res http.ResponseWriter, req *http.Request, path []string) {
+	if req.Method != http.MethodGet {
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	if len(path) == 1 {
+		serverType, ok := api.serverTypes[parseFakeID(path[0])]
+		if !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "server type not found")
+			return
+		}
+
+		writeFakeJSON(res, http.StatusOK, schema.ServerTypeGetResponse{ServerType: *serverType})
+		return
+	}
+
+	name := req.URL.Query().Get("name")
+
+	serverTypes := []schema.ServerType{}
+	for _, id := range sortedIDs(api.serverTypes) {
+		if serverType := api.serverTypes[id]; name == "" || name == serverType.Name {
+			serverTypes = append(serverTypes, *serverType)
+		}
+	}
+
+	page, meta, ok := paginate(res, req, serverTypes)
+	if !ok {
+		return
+	}
+
+	writeFakeJSON(res, http.StatusOK, struct {
+		schema.ServerTypeListResponse
+		schema.MetaResponse
+	}{schema.ServerTypeListResponse{ServerTypes: page}, meta})
+}
+
+// serveSSHKeys handles requests of the "/ssh_keys" endpoint.
+func (api *FakeAPI) serveSSHKeys(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			query := req.URL.Query()
+
+			selector, ok := parseFakeLabelSelector(res, query)
+			if !ok {
+				return
+			}
+
+			sshKeys := []schema.SSHKey{}
+			for _, id := range sortedIDs(api.sshKeys) {
+				sshKey := api.sshKeys[id]
+
+				if query.Get("name") != "" && query.Get("name") != sshKey.Name {
+					continue
+				}
+				if query.Get("fingerprint") != "" && query.Get("fingerprint") != sshKey.Fingerprint {
+					continue
+				}
+				if !selector.Matches(labels.Set(sshKey.Labels)) {
+					continue
+				}
+
+				sshKeys = append(sshKeys, *sshKey)
+			}
+
+			page, meta, ok := paginate(res, req, sshKeys)
+			if !ok {
+				return
+			}
+
+			writeFakeJSON(res, http.StatusOK, struct {
+				schema.SSHKeyListResponse
+				schema.MetaResponse
+			}{schema.SSHKeyListResponse{SSHKeys: page}, meta})
+		case http.MethodPost:
+			var body schema.SSHKeyCreateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			sshKey := &schema.SSHKey{
+				ID:        api.nextID(0),
+				Name:      body.Name,
+				PublicKey: body.PublicKey,
+				Created:   time.Now(),
+				Labels:    map[string]string{},
+			}
+			sshKey.Fingerprint = fmt.Sprintf("00:00:00:00:00:00:00:00:00:00:00:00:00:00:%02x:%02x", sshKey.ID/256%256, sshKey.ID%256)
+			if body.Labels != nil {
+				sshKey.Labels = *body.Labels
+			}
+
+			api.sshKeys[sshKey.ID] = sshKey
+
+			writeFakeJSON(res, http.StatusCreated, schema.SSHKeyCreateResponse{SSHKey: *sshKey})
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	sshKey, ok := api.sshKeys[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "SSH key not found")
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeFakeJSON(res, http.StatusOK, schema.SSHKeyGetResponse{SSHKey: *sshKey})
+	case http.MethodDelete:
+		delete(api.sshKeys, sshKey.ID)
+		writeFakeNoContent(res)
+	default:
+		writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+// serveVolumes handles requests of the "/volumes" endpoint.
+func (api *FakeAPI) serveVolumes(res http.ResponseWriter, req *http.Request, path []string) {
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			query := req.URL.Query()
+
+			selector, ok := parseFakeLabelSelector(res, query)
+			if !ok {
+				return
+			}
+
+			volumes := []schema.Volume{}
+			for _, id := range sortedIDs(api.volumes) {
+				volume := api.volumes[id]
+
+				if (query.Get("name") == "" || query.Get("name") == volume.Name) && selector.Matches(labels.Set(volume.Labels)) {
+					volumes = append(volumes, *volume)
+				}
+			}
+
+			page, meta, ok := paginate(res, req, volumes)
+			if !ok {
+				return
+			}
+
+			writeFakeJSON(res, http.StatusOK, struct {
+				schema.VolumeListResponse
+				schema.MetaResponse
+			}{schema.VolumeListResponse{Volumes: page}, meta})
+		case http.MethodPost:
+			var body schema.VolumeCreateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			for _, volume := range api.volumes {
+				if volume.Name == body.Name {
+					writeFakeError(res, http.StatusConflict, "uniqueness_error", "name is already used")
+					return
+				}
+			}
+
+			volume := &schema.Volume{
+				ID:      api.nextID(0),
+				Name:    body.Name,
+				Status:  "creating",
+				Size:    body.Size,
+				Format:  body.Format,
+				Labels:  map[string]string{},
+				Created: time.Now(),
+			}
+			if body.Labels != nil {
+				volume.Labels = *body.Labels
+			}
+			if location, ok := body.Location.(string); ok {
+				volume.Location = schema.Location{Name: location}
+			}
+
+			volume.LinuxDevice = fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", volume.ID)
+
+			nextActions := []schema.Action{}
+
+			if body.Server != nil {
+				server, ok := api.servers[*body.Server]
+				if !ok {
+					writeFakeError(res, http.StatusNotFound, "not_found", "server not found")
+					return
+				}
+
+				serverID := server.ID
+				volume.Server = &serverID
+				volume.Location = server.Datacenter.Location
+
+				nextActions = append(nextActions, *api.newAction("attach_volume", "volume", []int{volume.ID}, nil))
+			}
+
+			api.volumes[volume.ID] = volume
+
+			action := api.newAction("create_volume", "volume", []int{volume.ID}, func() {
+				volume.Status = "available"
+			})
+
+			writeFakeJSON(res, http.StatusCreated, schema.VolumeCreateResponse{Volume: *volume, Action: action, NextActions: nextActions})
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	volume, ok := api.volumes[parseFakeID(path[0])]
+	if !ok {
+		writeFakeError(res, http.StatusNotFound, "not_found", "volume not found")
+		return
+	}
+
+	if len(path) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			writeFakeJSON(res, http.StatusOK, schema.VolumeGetResponse{Volume: *volume})
+		case http.MethodPut:
+			var body schema.VolumeUpdateRequest
+			if !decodeFakeBody(res, req, &body) {
+				return
+			}
+
+			if body.Name != "" {
+				volume.Name = body.Name
+			}
+			if body.Labels != nil {
+				volume.Labels = *body.Labels
+			}
+
+			writeFakeJSON(res, http.StatusOK, schema.VolumeUpdateResponse{Volume: *volume})
+		case http.MethodDelete:
+			if volume.Server != nil {
+				writeFakeError(res, http.StatusConflict, "resource_in_use", "volume is attached")
+				return
+			}
+
+			delete(api.volumes, volume.ID)
+			writeFakeNoContent(res)
+		default:
+			writeFakeError(res, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		return
+	}
+
+	if path[1] != "actions" {
+		writeFakeError(res, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+
+	if len(path) == 2 {
+		api.listResourceActions(res, req, "volume", volume.ID)
+		return
+	}
+
+	switch path[2] {
+	case "attach":
+		var body schema.VolumeActionAttachVolumeRequest
+		if !decodeFakeBody(res, req, &body) {
+			return
+		}
+
+		if _, ok := api.servers[body.Server]; !ok {
+			writeFakeError(res, http.StatusNotFound, "not_found", "server not found")
+			return
+		}
+
+		serverID := body.Server
+		volume.Server = &serverID
+
+		writeFakeJSON(res, http.StatusCreated, schema.VolumeActionAttachVolumeResponse{
+			Action: *api.newAction("attach_volume", "volume", []int{volume.ID}, nil),
+		})
+	case "detach":
+		volume.Server = nil
+
+		writeFakeJSON(res, http.StatusCreated, schema.VolumeActionDetachVolumeResponse{
+			Action: *api.newAction("detach_volume", "volume", []int{volume.ID}, nil),
+		})
+	default:
+		writeFakeError(res, http.StatusNotFound, "not_found", "action not supported")
+	}
+}
+
+// paginate returns the requested page of the items given together with the pagination meta data.
+func paginate[T any](res http.ResponseWriter, req *http.Request, items []T) ([]T, schema.MetaResponse, bool) {
+	query := req.URL.Query()
+	page := 1
+	perPage := fakeDefaultPerPage
+
+	if value := query.Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", "invalid page")
+			return nil, schema.MetaResponse{}, false
+		}
+		page = parsed
+	}
+
+	if value := query.Get("per_page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			writeFakeError(res, http.StatusBadRequest, "invalid_input", "invalid per_page")
+			return nil, schema.MetaResponse{}, false
+		}
+		perPage = min(parsed, fakeMaxPerPage)
+	}
+
+	lastPage := max((len(items)+perPage-1)/perPage, 1)
+	pagination := &schema.MetaPagination{
+		Page:         page,
+		PerPage:      perPage,
+		LastPage:     lastPage,
+		TotalEntries: len(items),
+	}
+	if page > 1 {
+		pagination.PreviousPage = page - 1
+	}
+	if page < lastPage {
+		pagination.NextPage = page + 1
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	return items[start:end], schema.MetaResponse{Meta: schema.Meta{Pagination: pagination}}, true
+}
+
+// parseFakeLabelSelector parses the "label_selector" query parameter given.
+func parseFakeLabelSelector(res http.ResponseWriter, query url.Values) (labels.Selector, bool) {
+	selector, err := labels.Parse(query.Get("label_selector"))
+	if err != nil {
+		writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid label_selector: %s", err))
+		return nil, false
+	}
+
+	return selector, true
+}
+
+// parseFakeID returns the resource ID given or -1 if it is invalid.
+func parseFakeID(value string) int {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return -1
+	}
+
+	return id
+}
+
+// decodeFakeBody decodes the JSON request body into the value given and writes an error response if that fails.
+func decodeFakeBody(res http.ResponseWriter, req *http.Request, value interface{}) bool {
+	if err := json.NewDecoder(req.Body).Decode(value); err != nil {
+		writeFakeError(res, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid request body: %s", err))
+		return false
+	}
+
+	return true
+}
+
+// writeFakeJSON writes the JSON response given.
+func writeFakeJSON(res http.ResponseWriter, statusCode int, value interface{}) {
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(res).Encode(value); err != nil {
+		panic(err)
+	}
+}
+
+// writeFakeNoContent writes an empty response.
+func writeFakeNoContent(res http.ResponseWriter) {
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// writeFakeError writes the HCloud API error given.
+func writeFakeError(res http.ResponseWriter, statusCode int, code, message string) {
+	writeFakeJSON(res, statusCode, schema.ErrorResponse{Error: schema.Error{Code: code, Message: message}})
+}
+
+// containsString returns true if the value given is contained in the list.
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedIDs returns the keys of the map given in ascending order.
+func sortedIDs[T any](resources map[int]T) []int {
+	ids := make([]int, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
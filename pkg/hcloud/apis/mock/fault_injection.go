@@ -0,0 +1,187 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides all methods required to simulate a driver
+package mock
+
+import (
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// Fault describes a scripted failure of the mock HCloud API
+type Fault struct {
+	// Latency delays the response
+	Latency time.Duration
+	// StatusCode is the HTTP status code to respond with. The request is passed on if it is 0.
+	StatusCode int
+	// Code is the HCloud error code of the response
+	Code string
+	// Message is the HCloud error message of the response
+	Message string
+	// Body is sent instead of an error generated from Code and Message if set
+	Body string
+	// Times is the number of requests the fault applies to. The fault applies to all requests if it is 0.
+	Times int
+	// Before is called before the request is handled if set, e.g. to simulate concurrent changes
+	Before func()
+}
+
+// ActionFault describes a scripted failure of an action of the fake HCloud API
+type ActionFault struct {
+	// Stuck actions never complete
+	Stuck bool
+	// Code is the error code the action fails with if set
+	Code string
+	// Message is the error message the action fails with
+	Message string
+}
+
+// scriptedFault is a fault registered for requests matching method and path pattern
+type scriptedFault struct {
+	method    string
+	pattern   string
+	fault     Fault
+	remaining int
+}
+
+// FaultInjector is a HTTP handler injecting scripted faults before passing requests on to the wrapped handler
+type FaultInjector struct {
+	next   http.Handler
+	mutex  sync.Mutex
+	faults []*scriptedFault
+}
+
+// NewFaultInjector returns a new fault injector wrapping the handler given.
+//
+// PARAMETERS
+// next http.Handler Handler to pass requests on to
+func NewFaultInjector(next http.Handler) *FaultInjector {
+	return &FaultInjector{next: next}
+}
+
+// Inject registers the fault given for requests matching the HTTP method and path pattern. Faults are applied in
+// the order of registration. An empty method matches all methods and patterns follow the syntax of path.Match, e.g.
+// "/servers/*/actions/poweron".
+//
+// PARAMETERS
+// method  string HTTP method to match
+// pattern string Request path pattern to match
+// fault   Fault  Fault to inject
+func (injector *FaultInjector) Inject(method, pattern string, fault Fault) {
+	injector.mutex.Lock()
+	defer injector.mutex.Unlock()
+
+	injector.faults = append(injector.faults, &scriptedFault{
+		method:    method,
+		pattern:   pattern,
+		fault:     fault,
+		remaining: fault.Times,
+	})
+}
+
+// Reset removes all registered faults.
+func (injector *FaultInjector) Reset() {
+	injector.mutex.Lock()
+	defer injector.mutex.Unlock()
+
+	injector.faults = nil
+}
+
+// nextFault returns the fault to apply to the request given and consumes it.
+//
+// PARAMETERS
+// req *http.Request HTTP request
+func (injector *FaultInjector) nextFault(req *http.Request) (Fault, bool) {
+	injector.mutex.Lock()
+	defer injector.mutex.Unlock()
+
+	for index, scripted := range injector.faults {
+		if scripted.method != "" && scripted.method != req.Method {
+			continue
+		}
+
+		if matched, _ := path.Match(scripted.pattern, req.URL.Path); !matched {
+			continue
+		}
+
+		if scripted.remaining > 0 {
+			scripted.remaining--
+
+			if scripted.remaining == 0 {
+				injector.faults = append(injector.faults[:index], injector.faults[index+1:]...)
+			}
+		}
+
+		return scripted.fault, true
+	}
+
+	return Fault{}, false
+}
+
+// ServeHTTP applies the next matching fault or passes the request on.
+//
+// PARAMETERS
+// res http.ResponseWriter HTTP response writer
+// req *http.Request       HTTP request
+func (injector *FaultInjector) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	fault, ok := injector.nextFault(req)
+
+	if ok && fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	if ok && fault.Before != nil {
+		fault.Before()
+	}
+
+	if !ok || fault.StatusCode == 0 {
+		injector.next.ServeHTTP(res, req)
+		return
+	}
+
+	if fault.Body != "" {
+		res.Header().Set("Content-Type", "application/json; charset=utf-8")
+		res.WriteHeader(fault.StatusCode)
+
+		if _, err := res.Write([]byte(fault.Body)); err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	code := fault.Code
+	if code == "" {
+		code = "service_error"
+	}
+
+	message := fault.Message
+	if message == "" {
+		message = http.StatusText(fault.StatusCode)
+	}
+
+	writeFakeError(res, fault.StatusCode, code, message)
+}
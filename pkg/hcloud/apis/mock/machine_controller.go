@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
@@ -36,6 +37,7 @@ const (
 	"type": "snapshot",
 	"status": "available",
 	"name": "ubuntu-20.04",
+	"architecture": "x86",
 	"description": "Proudly copied from the Hetzner Cloud API documentation",
 	"image_size": 2.3,
 	"disk_size": 10,
@@ -62,6 +64,7 @@ const (
 	"created": "2016-01-30T23:50:00+00:00",
 	"public_net": {
 		"ipv4": {
+			"id": 42,
 			"ip": "1.2.3.4",
 			"blocked": false,
 			"dns_ptr": "server01.test.invalid"
@@ -149,13 +152,108 @@ const (
 		"rebuild": false
 	},
 	"labels": {},
-	"volumes": [],
+	"volumes": [ 42 ],
 	"load_balancers": []
 }
 	`
+	jsonActionData = `
+{
+	"id": 42,
+	"command": "test",
+	"status": "running",
+	"progress": 0,
+	"started": "2016-01-30T23:50:00+00:00",
+	"finished": null,
+	"resources": [],
+	"error": null
+}
+	`
+	jsonPlacementGroupData = `
+{
+	"created": "2019-01-08T12:10:00+00:00",
+	"id": 42,
+	"labels": { },
+	"name": "Simulated Placement Group",
+	"servers": [ 42 ],
+	"type": "spread"
+}
+	`
+	jsonPrimaryIPDataTemplate = `
+{
+	"id": %d,
+	"ip": "1.2.3.4",
+	"labels": {},
+	"name": "test-primary-ip",
+	"type": "ipv4",
+	"protection": {
+		"delete": false
+	},
+	"dns_ptr": [],
+	"assignee_id": %s,
+	"assignee_type": "server",
+	"auto_delete": false,
+	"blocked": false,
+	"created": "2016-01-30T23:50:00+00:00",
+	"datacenter": {
+		"id": 1,
+		"name": "%s",
+		"description": "Test",
+		"location": {
+			"id": 2,
+			"name": "hel1",
+			"description": "Helsinki DC 2",
+			"country": "FI",
+			"city": "Helsinki",
+			"latitude": 60.1698,
+			"longitude": 24.9386,
+			"network_zone": "eu-central"
+		},
+		"server_types": {
+			"supported": [ 1, 2, 3 ],
+			"available": [ 1, 2, 3 ],
+			"available_for_migration": [ 1, 2, 3 ]
+		}
+	}
+}
+	`
+	jsonVolumeDataTemplate = `
+{
+	"id": %d,
+	"name": "%s",
+	"server": %d,
+	"status": "available",
+	"location": {
+		"id": 2,
+		"name": "hel1",
+		"description": "Helsinki DC 2",
+		"country": "FI",
+		"city": "Helsinki",
+		"latitude": 60.1698,
+		"longitude": 24.9386,
+		"network_zone": "eu-central"
+	},
+	"size": 10,
+	"format": "ext4",
+	"protection": {
+		"delete": false
+	},
+	"labels": {},
+	"linux_device": "/dev/disk/by-id/scsi-0HC_Volume_%d",
+	"created": "2016-01-30T23:50:00+00:00"
+}
+	`
+	TestFirewallID           = 42
+	TestFirewallName         = "test-firewall"
 	TestNamespace            = "test"
+	TestNetworkID            = 1
+	TestNetworkName          = "test-network"
+	TestPlacementGroupName   = "Simulated Placement Group"
+	TestPrimaryIPID          = 42
+	TestPrimaryIPSelector    = "pool=test"
 	TestServerID             = 42
 	TestServerNameTemplate   = "machine-%d"
+	TestVolumeID             = 42
+	TestVolumeNameTemplate   = "machine-%d-volume-0"
 	testServersLabelSelector = "mcm.gardener.cloud/role=node,topology.kubernetes.io/zone=hel1-dc2"
 )
 
@@ -242,6 +340,84 @@ func newJsonServerData(serverID int, serverState string) string {
 	return fmt.Sprintf(jsonServerDataTemplate, serverID, testServerName, serverState, TestServerType, TestZone, jsonImageData)
 }
 
+// newJsonVolumeData generates a JSON volume data object for testing purposes.
+//
+// PARAMETERS
+// volumeID int Volume ID to use
+// serverID int Server ID the volume is attached to
+func newJsonVolumeData(volumeID int, serverID int) string {
+	testVolumeName := fmt.Sprintf(TestVolumeNameTemplate, serverID)
+	return fmt.Sprintf(jsonVolumeDataTemplate, volumeID, testVolumeName, serverID, volumeID)
+}
+
+// SetupActionsEndpointOnMux configures an "/actions" endpoint on the mux given. All actions are reported as successfully
+// finished.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupActionsEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc("/actions/", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		if strings.ToLower(req.Method) != "get" {
+			panic("Unsupported HTTP method call")
+		}
+
+		actionID, err := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/actions/"))
+		if err != nil {
+			res.WriteHeader(http.StatusNotFound)
+			res.Write([]byte("{ \"error\": { \"code\": \"not_found\", \"message\": \"action not found\" } }"))
+			return
+		}
+
+		res.WriteHeader(http.StatusOK)
+
+		if _, err := fmt.Fprintf(res, "{ \"action\": %s }", strings.Replace(strings.Replace(jsonActionData, "\"id\": 42", fmt.Sprintf("\"id\": %d", actionID), 1), "\"running\"", "\"success\"", 1)); err != nil {
+			panic(err)
+		}
+	})
+}
+
+// SetupFirewallsEndpointOnMux configures a "/firewalls" endpoint on the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupFirewallsEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc("/firewalls", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		res.WriteHeader(http.StatusOK)
+
+		queryParams := req.URL.Query()
+		var response bytes.Buffer
+		response.Write([]byte(`
+{
+	"firewalls": [
+		`))
+
+		if queryParams.Get("name") == TestFirewallName {
+			response.Write([]byte(fmt.Sprintf(`
+{
+	"id": %d,
+	"name": "%s",
+	"labels": {},
+	"created": "2016-01-30T23:50:00+00:00",
+	"rules": [],
+	"applied_to": []
+}
+			`, TestFirewallID, TestFirewallName)))
+		}
+
+		response.Write([]byte(`
+	]
+}
+		`))
+		if _, err := res.Write(response.Bytes()); err != nil {
+			panic(err)
+		}
+	})
+}
+
 // SetupFloatingIPsEndpointOnMux configures a "/floating_ips" endpoint on the mux given.
 //
 // PARAMETERS
@@ -296,6 +472,99 @@ func SetupImagesEndpointOnMux(mux *http.ServeMux) {
 	})
 }
 
+// SetupNetworksEndpointOnMux configures a "/networks" endpoint on the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupNetworksEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc("/networks", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		res.WriteHeader(http.StatusOK)
+
+		queryParams := req.URL.Query()
+		var response bytes.Buffer
+		response.Write([]byte(`
+{
+	"networks": [
+		`))
+
+		if queryParams.Get("name") == TestNetworkName {
+			response.Write([]byte(fmt.Sprintf(`
+{
+	"id": %d,
+	"name": "%s",
+	"ip_range": "10.0.0.0/16",
+	"subnets": [
+		{
+			"type": "cloud",
+			"ip_range": "10.0.0.0/24",
+			"network_zone": "eu-central",
+			"gateway": "10.0.0.1"
+		}
+	],
+	"routes": [],
+	"servers": [ %d ],
+	"protection": {
+		"delete": false
+	},
+	"labels": {},
+	"created": "2016-01-30T23:50:00+00:00"
+}
+			`, TestNetworkID, TestNetworkName, TestServerID)))
+		}
+
+		response.Write([]byte(`
+	]
+}
+		`))
+		if _, err := res.Write(response.Bytes()); err != nil {
+			panic(err)
+		}
+	})
+}
+
+// SetupPrimaryIPsEndpointOnMux configures "/primary_ips" and "/primary_ips/42" endpoints on the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupPrimaryIPsEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc("/primary_ips", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		res.WriteHeader(http.StatusOK)
+
+		var response bytes.Buffer
+		response.Write([]byte(`
+{
+	"primary_ips": [
+		`))
+
+		if req.URL.Query().Get("label_selector") == TestPrimaryIPSelector {
+			response.Write([]byte(fmt.Sprintf(jsonPrimaryIPDataTemplate, TestPrimaryIPID, "null", TestZone)))
+		}
+
+		response.Write([]byte(`
+	]
+}
+		`))
+		if _, err := res.Write(response.Bytes()); err != nil {
+			panic(err)
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("/primary_ips/%d", TestPrimaryIPID), func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		if strings.ToLower(req.Method) != "get" && strings.ToLower(req.Method) != "put" {
+			panic("Unsupported HTTP method call")
+		}
+
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(fmt.Sprintf("{ \"primary_ip\": %s }", fmt.Sprintf(jsonPrimaryIPDataTemplate, TestPrimaryIPID, fmt.Sprint(TestServerID), TestZone))))
+	})
+}
+
 // SetupServersEndpointOnMux configures a "/servers" endpoint on the mux given.
 //
 // PARAMETERS
@@ -345,7 +614,7 @@ func SetupServersEndpointOnMux(mux *http.ServeMux, emptyOnFirstRequest bool) {
 			}
 
 			jsonServerData := newJsonServerData(TestServerID, "starting")
-			if _, err := fmt.Fprintf(res, "{ \"server\": %s, \"root_password\": \"test\" }", jsonServerData); err != nil {
+			if _, err := fmt.Fprintf(res, "{ \"server\": %s, \"action\": %s, \"next_actions\": [], \"root_password\": \"test\" }", jsonServerData, jsonActionData); err != nil {
 				panic(err)
 			}
 		} else {
@@ -354,6 +623,50 @@ func SetupServersEndpointOnMux(mux *http.ServeMux, emptyOnFirstRequest bool) {
 	})
 }
 
+// SetupServerTypesEndpointOnMux configures a "/server_types" endpoint on the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupServerTypesEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc("/server_types", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		res.WriteHeader(http.StatusOK)
+
+		queryParams := req.URL.Query()
+		var response bytes.Buffer
+		response.Write([]byte(`
+{
+	"server_types": [
+		`))
+
+		if queryParams.Get("name") == TestServerType {
+			response.Write([]byte(fmt.Sprintf(`
+{
+	"id": 1,
+	"name": "%s",
+	"description": "Simulated server type",
+	"cores": 1,
+	"memory": 2,
+	"disk": 20,
+	"storage_type": "local",
+	"cpu_type": "shared",
+	"architecture": "x86",
+	"prices": []
+}
+			`, TestServerType)))
+		}
+
+		response.Write([]byte(`
+	]
+}
+		`))
+		if _, err := res.Write(response.Bytes()); err != nil {
+			panic(err)
+		}
+	})
+}
+
 // SetupSshKeysEndpointOnMux configures a "/ssh_keys" endpoint on the mux given.
 //
 // PARAMETERS
@@ -402,23 +715,55 @@ func SetupTestPlacementGroupEndpointOnMux(mux *http.ServeMux) {
 	mux.HandleFunc(fmt.Sprintf("/placement_groups/%s", TestPlacementGroupID), func(res http.ResponseWriter, req *http.Request) {
 		res.Header().Add("Content-Type", "application/json; charset=utf-8")
 
-		if strings.ToLower(req.Method) != "get" {
+		if strings.ToLower(req.Method) == "delete" {
+			res.Header().Del("Content-Type")
+			res.WriteHeader(http.StatusNoContent)
+		} else if strings.ToLower(req.Method) == "get" {
+			res.WriteHeader(http.StatusOK)
+			res.Write([]byte(fmt.Sprintf("{ \"placement_group\": %s }", jsonPlacementGroupData)))
+		} else {
 			panic("Unsupported HTTP method call")
 		}
-		res.WriteHeader(http.StatusOK)
+	})
+}
 
-		res.Write([]byte(`
+// SetupPlacementGroupsEndpointOnMux configures a "/placement_groups" endpoint on the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupPlacementGroupsEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc("/placement_groups", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		if strings.ToLower(req.Method) == "get" {
+			res.WriteHeader(http.StatusOK)
+
+			var response bytes.Buffer
+			response.Write([]byte(`
 {
-	"placement_group": {
-		"created": "2019-01-08T12:10:00+00:00",
-		"id": 42,
-		"labels": { },
-		"name": "Simulated Placement Group",
-		"servers": [ ],
-		"type": "spread"
-	}
+	"placement_groups": [
+			`))
+
+			if req.URL.Query().Get("name") == TestPlacementGroupName {
+				response.Write([]byte(jsonPlacementGroupData))
+			}
+
+			response.Write([]byte(`
+	]
 }
 			`))
+			if _, err := res.Write(response.Bytes()); err != nil {
+				panic(err)
+			}
+		} else if strings.ToLower(req.Method) == "post" {
+			res.WriteHeader(http.StatusCreated)
+
+			if _, err := fmt.Fprintf(res, "{ \"placement_group\": %s, \"action\": null }", jsonPlacementGroupData); err != nil {
+				panic(err)
+			}
+		} else {
+			panic("Unsupported HTTP method call")
+		}
 	})
 }
 
@@ -434,7 +779,7 @@ func SetupTestServerEndpointOnMux(mux *http.ServeMux) {
 
 		if strings.ToLower(req.Method) == "delete" {
 			res.WriteHeader(http.StatusOK)
-			res.Write([]byte(fmt.Sprintf("{ \"server\": %s }", newJsonServerData(TestServerID, "shutdown_server"))))
+			res.Write([]byte(fmt.Sprintf("{ \"action\": %s }", strings.Replace(jsonActionData, "\"test\"", "\"delete_server\"", 1))))
 		} else if strings.ToLower(req.Method) == "get" {
 			res.WriteHeader(http.StatusOK)
 			res.Write([]byte(fmt.Sprintf("{ \"server\": %s }", newJsonServerData(TestServerID, "running"))))
@@ -449,30 +794,79 @@ func SetupTestServerEndpointOnMux(mux *http.ServeMux) {
 		res.WriteHeader(http.StatusOK)
 		res.Write([]byte("{ \"actions\": [] }"))
 	})
+}
 
-	mux.HandleFunc(fmt.Sprintf("%s/actions/add_to_placement_group", baseURL), func(res http.ResponseWriter, req *http.Request) {
+// SetupVolumesEndpointOnMux configures "/volumes" and "/volumes/42" endpoints on the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupVolumesEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc("/volumes", func(res http.ResponseWriter, req *http.Request) {
 		res.Header().Add("Content-Type", "application/json; charset=utf-8")
 
-		if strings.ToLower(req.Method) != "post" {
+		if strings.ToLower(req.Method) == "get" {
+			res.WriteHeader(http.StatusOK)
+
+			var response bytes.Buffer
+			response.Write([]byte(`
+{
+	"volumes": [
+			`))
+
+			if req.URL.Query().Get("name") == fmt.Sprintf(TestVolumeNameTemplate, TestServerID) {
+				response.Write([]byte(newJsonVolumeData(TestVolumeID, TestServerID)))
+			}
+
+			response.Write([]byte(`
+	]
+}
+			`))
+			if _, err := res.Write(response.Bytes()); err != nil {
+				panic(err)
+			}
+		} else if strings.ToLower(req.Method) == "post" {
+			res.WriteHeader(http.StatusCreated)
+
+			jsonVolumeData := newJsonVolumeData(TestVolumeID, TestServerID)
+			if _, err := fmt.Fprintf(res, "{ \"volume\": %s, \"action\": %s, \"next_actions\": [] }", jsonVolumeData, jsonActionData); err != nil {
+				panic(err)
+			}
+		} else {
 			panic("Unsupported HTTP method call")
 		}
-		res.WriteHeader(http.StatusCreated)
+	})
 
-		jsonData := make([]byte, req.ContentLength)
-		req.Body.Read(jsonData)
+	baseURL := fmt.Sprintf("/volumes/%d", TestVolumeID)
 
-		var data map[string]interface{}
+	mux.HandleFunc(baseURL, func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
 
-		jsonErr := json.Unmarshal(jsonData, &data)
-		if jsonErr != nil {
-			panic(jsonErr)
+		if strings.ToLower(req.Method) == "delete" {
+			res.Header().Del("Content-Type")
+			res.WriteHeader(http.StatusNoContent)
+		} else if strings.ToLower(req.Method) == "get" {
+			res.WriteHeader(http.StatusOK)
+			res.Write([]byte(fmt.Sprintf("{ \"volume\": %s }", newJsonVolumeData(TestVolumeID, TestServerID))))
+		} else {
+			panic("Unsupported HTTP method call")
 		}
+	})
 
-		if placementGroupID, ok := data["placement_group"]; !ok || testPlacementGroupJsonValue != placementGroupID {
-			panic("Invalid HTTP method data")
-		}
+	mux.HandleFunc(fmt.Sprintf("%s/actions", baseURL), func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
 
-		res.Write([]byte("{ \"action\": {} }"))
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte("{ \"actions\": [] }"))
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/actions/detach", baseURL), func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+		if strings.ToLower(req.Method) != "post" {
+			panic("Unsupported HTTP method call")
+		}
 
+		res.WriteHeader(http.StatusCreated)
+		res.Write([]byte(fmt.Sprintf("{ \"action\": %s }", jsonActionData)))
 	})
 }
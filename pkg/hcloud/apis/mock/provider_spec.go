@@ -22,13 +22,20 @@ import (
 )
 
 const (
-	TestCluster             = "xyz"
-	TestImageName           = "ubuntu-20.04"
-	TestProviderSpec        = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"placementGroupID\":\"42\",\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\"}"
-	TestServerType          = "cx11-ceph"
-	TestSSHFingerprint      = "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff"
-	TestZone                = "hel1-dc2"
-	TestInvalidProviderSpec = "{\"test\":\"invalid\"}"
+	TestArmServerType                  = "cax11"
+	TestCluster                        = "xyz"
+	TestImageName                      = "ubuntu-20.04"
+	TestProviderSpec                   = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"placementGroupID\":\"42\",\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\"}"
+	TestProviderSpecWithVolumes        = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\",\"volumes\":[{\"size\":10,\"format\":\"ext4\",\"automount\":true}]}"
+	TestProviderSpecWithNetworks       = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\",\"networks\":[{\"name\":\"test-network\",\"ipRange\":\"10.0.0.0/24\"}]}"
+	TestProviderSpecWithFallbacks      = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"fallbackZones\":[\"hel1-dc3\"],\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"serverTypes\":[\"cx21\"],\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\"}"
+	TestProviderSpecWithFirewalls      = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\",\"firewalls\":[{\"name\":\"test-firewall\"}]}"
+	TestProviderSpecWithPlacementGroup = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\",\"placementGroup\":{\"name\":\"Simulated Placement Group\",\"autoCreate\":true}}"
+	TestProviderSpecWithPublicNet      = "{\"cluster\":\"xyz\",\"zone\":\"hel1-dc2\",\"imageName\":\"ubuntu-20.04\",\"serverType\":\"cx11-ceph\",\"sshFingerprint\":\"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff\",\"publicNet\":{\"enableIPv6\":false,\"ipv4Pool\":{\"labelSelector\":\"pool=test\",\"deletionPolicy\":\"Delete\"}}}"
+	TestServerType                     = "cx11-ceph"
+	TestSSHFingerprint                 = "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff"
+	TestZone                           = "hel1-dc2"
+	TestInvalidProviderSpec            = "{\"test\":\"invalid\"}"
 )
 
 // ManipulateProviderSpec changes given provider specification.
@@ -38,7 +45,7 @@ const (
 // data         map[string]interface{} Members to change
 func ManipulateProviderSpec(providerSpec *apis.ProviderSpec, data map[string]interface{}) *apis.ProviderSpec {
 	for key, value := range data {
-		manipulateStruct(&providerSpec, key, value)
+		manipulateStruct(providerSpec, key, value)
 	}
 
 	return providerSpec
@@ -20,13 +20,14 @@ package mock
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
 )
 
 const (
-	TestPlacementGroupID        = "42"
-	testPlacementGroupJsonValue = float64(42)
+	TestPlacementGroupID = "42"
 )
 
 // MockTestEnv represents the test environment for testing HCloud API calls
@@ -34,6 +35,10 @@ type MockTestEnv struct {
 	Server *httptest.Server
 	Mux    *http.ServeMux
 	Client *hcloud.Client
+	// Faults injects scripted failures into requests before they are handled by the mux
+	Faults *FaultInjector
+	// API is the stateful fake HCloud API if the environment has been created with NewFakeTestEnv
+	API *FakeAPI
 }
 
 // Teardown shuts down the test environment server
@@ -43,12 +48,15 @@ func (env *MockTestEnv) Teardown() {
 	env.Server = nil
 	env.Mux = nil
 	env.Client = nil
+	env.Faults = nil
+	env.API = nil
 }
 
 // NewMockTestEnv generates a new, unconfigured test environment for testing purposes.
 func NewMockTestEnv() MockTestEnv {
 	mux := http.NewServeMux()
-	server := httptest.NewServer(mux)
+	faults := NewFaultInjector(mux)
+	server := httptest.NewServer(faults)
 
 	client := hcloud.NewClient(
 		hcloud.WithEndpoint(server.URL),
@@ -59,5 +67,40 @@ func NewMockTestEnv() MockTestEnv {
 		Server: server,
 		Mux:    mux,
 		Client: client,
+		Faults: faults,
 	}
 }
+
+// NewFakeTestEnv generates a new test environment backed by a stateful fake HCloud API. The fake API contains the x86
+// and ARM server types, datacenters, images, SSH key and placement group referenced by the test provider specifications.
+func NewFakeTestEnv() MockTestEnv {
+	env := NewMockTestEnv()
+	env.API = NewFakeAPI()
+
+	placementGroupID, _ := strconv.Atoi(TestPlacementGroupID)
+	imageName := TestImageName
+
+	location := schema.Location{Name: "hel1", NetworkZone: "eu-central"}
+	pricings := []schema.PricingServerTypePrice{{Location: location.Name}}
+
+	smallServerTypeID := env.API.AddServerType(schema.ServerType{Name: TestServerType, Cores: 1, Architecture: "x86", Prices: pricings})
+	largeServerTypeID := env.API.AddServerType(schema.ServerType{Name: "cx21", Cores: 2, Architecture: "x86", Prices: pricings})
+	armServerTypeID := env.API.AddServerType(schema.ServerType{Name: TestArmServerType, Cores: 2, Architecture: "arm", Prices: pricings})
+
+	for _, zone := range []string{TestZone, "hel1-dc3"} {
+		datacenter := schema.Datacenter{Name: zone, Location: location}
+		datacenter.ServerTypes.Supported = []int{smallServerTypeID, largeServerTypeID, armServerTypeID}
+		datacenter.ServerTypes.Available = []int{smallServerTypeID, largeServerTypeID, armServerTypeID}
+
+		env.API.AddDatacenter(datacenter)
+	}
+
+	env.API.AddImage(schema.Image{Name: &imageName, Type: "system", Architecture: "x86"})
+	env.API.AddImage(schema.Image{Name: &imageName, Type: "system", Architecture: "arm"})
+	env.API.AddSSHKey(schema.SSHKey{Name: "test-key", Fingerprint: TestSSHFingerprint})
+	env.API.AddPlacementGroup(schema.PlacementGroup{ID: placementGroupID, Name: TestPlacementGroupName, Type: "spread"})
+
+	env.API.SetupOnMux(env.Mux)
+
+	return env
+}
@@ -17,15 +17,164 @@ limitations under the License.
 // Package apis is the main package for provider specific APIs
 package apis
 
+// DeletionPolicy defines what happens to a resource bound to a machine if the machine is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the resource together with the machine
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain detaches the resource but keeps it
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// VolumeNameMachinePlaceholder is replaced with the machine name in volume names
+const VolumeNameMachinePlaceholder = "{machine}"
+
 // ProviderSpec is the spec to be used while parsing the calls.
 type ProviderSpec struct {
-	Cluster        string `json:"cluster"`
-	Zone           string `json:"zone"`
-	ServerType     string `json:"serverType"`
-	ImageName      string `json:"imageName"`
-	SSHFingerprint string `json:"sshFingerprint"`
-
-	PlacementGroupID string `json:"placementGroupID,omitempty"`
-	FloatingPoolName string `json:"floatingPoolName,omitempty"`
-	NetworkName      string `json:"networkName,omitempty"`
+	Cluster string `json:"cluster"`
+	// Labels are added to the servers, floating IPs, volumes and primary IPs of machines. Labels reserved for the
+	// provider must not be used.
+	Labels map[string]string `json:"labels,omitempty"`
+	// NodeTemplateLabels are the keys of the node template labels of a machine added to its server.
+	NodeTemplateLabels []string `json:"nodeTemplateLabels,omitempty"`
+	Zone               string   `json:"zone"`
+	// FallbackZones are datacenters of the same region tried in order if the server types are unavailable in Zone.
+	FallbackZones []string `json:"fallbackZones,omitempty"`
+	ServerType    string   `json:"serverType"`
+	// ServerTypes are tried in order if ServerType is unavailable.
+	ServerTypes []string `json:"serverTypes,omitempty"`
+	ImageName   string   `json:"imageName,omitempty"`
+	// Image selects the image by ID, name or label selector. It is mutually exclusive with ImageName.
+	Image *ImageSpec `json:"image,omitempty"`
+
+	// SSHFingerprint is deprecated. Use SSHKeys instead.
+	SSHFingerprint string       `json:"sshFingerprint,omitempty"`
+	SSHKeys        []SSHKeySpec `json:"sshKeys,omitempty"`
+	// SSHKeysOptional allows machines to be created without any SSH key, e.g. if no SSH key is configured or label
+	// selectors do not match any. Note that HCloud sends the root password of servers created without SSH keys by
+	// email and does not offer a way to disable this.
+	SSHKeysOptional bool `json:"sshKeysOptional,omitempty"`
+
+	// PlacementGroupID is deprecated. Use PlacementGroup instead.
+	PlacementGroupID string              `json:"placementGroupID,omitempty"`
+	PlacementGroup   *PlacementGroupSpec `json:"placementGroup,omitempty"`
+	FloatingPoolName string              `json:"floatingPoolName,omitempty"`
+	// NetworkName is deprecated. Use Networks instead.
+	NetworkName string         `json:"networkName,omitempty"`
+	Networks    []NetworkSpec  `json:"networks,omitempty"`
+	Volumes     []VolumeSpec   `json:"volumes,omitempty"`
+	Firewalls   []FirewallSpec `json:"firewalls,omitempty"`
+	PublicNet   *PublicNetSpec `json:"publicNet,omitempty"`
+	Preflight   *PreflightSpec `json:"preflight,omitempty"`
+	// Shutdown requests a graceful shutdown of servers before they are deleted.
+	Shutdown *ShutdownSpec `json:"shutdown,omitempty"`
+	// Protection requests HCloud protection of servers against deletion and rebuilds outside of MCM.
+	Protection *ProtectionSpec `json:"protection,omitempty"`
+	// AutoPowerOn lets InitializeMachine power servers of running machines on again if they have been stopped
+	// unexpectedly. Such servers are reported as unavailable otherwise.
+	AutoPowerOn bool `json:"autoPowerOn,omitempty"`
+}
+
+// ProtectionSpec configures the HCloud protection of servers. The protection is lifted by the provider before a
+// machine is deleted. HCloud requires the delete and rebuild protection of servers to have the same value.
+type ProtectionSpec struct {
+	Delete  bool `json:"delete,omitempty"`
+	Rebuild bool `json:"rebuild,omitempty"`
+}
+
+// ShutdownSpec configures the graceful ACPI shutdown of servers before they are deleted.
+type ShutdownSpec struct {
+	// Timeout is the time to wait for the server to shut down before it is powered off, e.g. "2m". It defaults to
+	// "1m".
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// PreflightSpec configures checks executed before a server is created. The HCloud API does not expose the limits of a
+// project. Project limits are therefore only checked against the values declared here and creations fail fast only if
+// they are set.
+type PreflightSpec struct {
+	// ServerType checks that the server type is available in the zone and priced for its location.
+	ServerType bool `json:"serverType,omitempty"`
+	// MaxServers is the server limit of the project as declared by the user. It is not checked if 0, the default.
+	MaxServers int `json:"maxServers,omitempty"`
+	// MaxCores is the CPU core limit of the project as declared by the user. It is not checked if 0, the default.
+	MaxCores int `json:"maxCores,omitempty"`
+}
+
+// ImageSpec selects the image of each machine by ID, name or label selector.
+type ImageSpec struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	// LabelSelector selects the newest available snapshot matching it.
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// Architecture restricts the images to the CPU architecture given ("x86" or "arm").
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// SSHKeySpec references one or more SSH keys to be injected into each machine.
+// Exactly one of Fingerprint, Name or LabelSelector must be set.
+type SSHKeySpec struct {
+	Fingerprint   string `json:"fingerprint,omitempty"`
+	Name          string `json:"name,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// PublicNetSpec is the spec of the public network interface of each machine.
+type PublicNetSpec struct {
+	// EnableIPv4 defaults to true.
+	EnableIPv4 *bool `json:"enableIPv4,omitempty"`
+	// EnableIPv6 defaults to true.
+	EnableIPv6 *bool              `json:"enableIPv6,omitempty"`
+	IPv4Pool   *PrimaryIPPoolSpec `json:"ipv4Pool,omitempty"`
+	IPv6Pool   *PrimaryIPPoolSpec `json:"ipv6Pool,omitempty"`
+}
+
+// PrimaryIPPoolSpec selects pre-allocated primary IPs to be assigned to machines.
+type PrimaryIPPoolSpec struct {
+	LabelSelector string `json:"labelSelector"`
+	// DeletionPolicy defaults to "Retain".
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// PlacementGroupSpec references the placement group of each machine by ID or name.
+type PlacementGroupSpec struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	// AutoCreate creates the placement group if it does not exist. The name defaults to "<cluster>-<zone>".
+	// Auto-created placement groups are deleted with their last member.
+	AutoCreate bool `json:"autoCreate,omitempty"`
+	// Type defaults to "spread".
+	Type string `json:"type,omitempty"`
+}
+
+// NetworkSpec is the spec of a private network attachment of each machine.
+// IP and IPRange are mutually exclusive. If none of them is set an IP is assigned automatically.
+type NetworkSpec struct {
+	Name string `json:"name"`
+	// IP is a static IP to assign in the network.
+	IP string `json:"ip,omitempty"`
+	// IPRange is the IP range of the subnet to assign an IP from.
+	IPRange  string   `json:"ipRange,omitempty"`
+	AliasIPs []string `json:"aliasIPs,omitempty"`
+}
+
+// FirewallSpec references one or more firewalls to be applied to each machine.
+// Exactly one of ID, Name or LabelSelector must be set.
+type FirewallSpec struct {
+	ID            int    `json:"id,omitempty"`
+	Name          string `json:"name,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// VolumeSpec is the spec of an additional volume created and attached for each machine.
+type VolumeSpec struct {
+	// Name is the volume name template. "{machine}" is replaced with the machine name.
+	Name string `json:"name,omitempty"`
+	// Size is the volume size in GB.
+	Size      int    `json:"size"`
+	Format    string `json:"format,omitempty"`
+	Automount bool   `json:"automount,omitempty"`
+	// DeletionPolicy defaults to "Delete".
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
@@ -0,0 +1,225 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitError is returned for HCloud API requests that can not be sent within the rate limit
+type RateLimitError struct {
+	// RetryAfter is the duration after which the request is expected to be accepted again
+	RetryAfter time.Duration
+}
+
+// Error returns the error message
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("HCloud API rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// GetRateLimitRetryAfter returns the retry hint if the given error was caused by an exhausted rate limit.
+//
+// PARAMETERS
+// err error Error to inspect
+func GetRateLimitRetryAfter(err error) (time.Duration, bool) {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr.RetryAfter, true
+	}
+
+	return 0, false
+}
+
+// rateLimiter tracks the rate limit budget of a HCloud API token as reported by the API
+type rateLimiter struct {
+	mutex     sync.Mutex
+	limit     int
+	remaining int
+	reset     time.Time
+	// next is the earliest time the next request may be sent after the budget has been used up
+	next  time.Time
+	known bool
+}
+
+// reserve reserves the budget for one request and returns how long the caller has to wait before sending it.
+//
+// PARAMETERS
+// now time.Time Current time
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.known || !l.reset.After(now) {
+		return 0
+	}
+
+	if l.remaining > 0 {
+		l.remaining--
+		return 0
+	}
+
+	// The budget is refilled linearly until it has been fully restored at the reset time.
+	interval := l.reset.Sub(now)
+	if missing := l.limit - l.remaining; missing > 0 {
+		interval /= time.Duration(missing)
+	}
+
+	slot := l.next
+	if slot.Before(now) {
+		slot = now.Add(interval)
+	}
+	l.next = slot.Add(interval)
+
+	return slot.Sub(now)
+}
+
+// retryAfter returns the duration until the budget for one more request is expected to be available.
+//
+// PARAMETERS
+// now time.Time Current time
+func (l *rateLimiter) retryAfter(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.known || !l.reset.After(now) {
+		return time.Second
+	}
+
+	interval := l.reset.Sub(now)
+	if missing := l.limit - l.remaining; missing > 0 {
+		interval /= time.Duration(missing)
+	}
+
+	if l.next.After(now) {
+		return l.next.Sub(now) + interval
+	}
+
+	return interval
+}
+
+// update records the rate limit budget reported by the API response headers given.
+//
+// PARAMETERS
+// header http.Header Response headers
+func (l *rateLimiter) update(header http.Header) {
+	limit, limitErr := strconv.Atoi(header.Get("RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(header.Get("RateLimit-Remaining"))
+	reset, resetErr := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64)
+
+	if limitErr != nil || remainingErr != nil || resetErr != nil {
+		return
+	}
+
+	resetTime := time.Unix(reset, 0)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// Responses of concurrent requests arrive out of order and the budget reserved locally may not be reflected by
+	// the API yet. The smaller budget is kept as long as the reset time is unchanged.
+	if l.known && resetTime.Equal(l.reset) && l.remaining < remaining {
+		remaining = l.remaining
+	}
+
+	l.limit = limit
+	l.remaining = remaining
+	l.reset = resetTime
+	l.known = true
+}
+
+// rateLimitedTransport delays HCloud API requests according to the rate limit budget shared by all users of a token
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+// wait blocks until the request given may be sent within the rate limit.
+// A RateLimitError is returned if the request context expires before that.
+//
+// PARAMETERS
+// req *http.Request Request to be sent
+func (t *rateLimitedTransport) wait(req *http.Request) error {
+	now := time.Now()
+
+	delay := t.limiter.reserve(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	ctx := req.Context()
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		return &RateLimitError{RetryAfter: delay}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return &RateLimitError{RetryAfter: delay}
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RoundTrip sends the request given once the rate limit permits. A request rejected with HTTP 429 is retried once
+// before a RateLimitError is returned.
+//
+// PARAMETERS
+// req *http.Request Request to be sent
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req); err != nil {
+			return nil, err
+		}
+
+		res, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		t.limiter.update(res.Header)
+
+		if res.StatusCode != http.StatusTooManyRequests {
+			return res, nil
+		}
+
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+
+		if attempt > 0 || (req.Body != nil && req.GetBody == nil) {
+			return nil, &RateLimitError{RetryAfter: t.limiter.retryAfter(time.Now())}
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
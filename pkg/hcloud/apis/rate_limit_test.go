@@ -0,0 +1,162 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimit", func() {
+	var (
+		requests  atomic.Int32
+		remaining atomic.Int32
+		server    *httptest.Server
+		client    *http.Client
+	)
+
+	BeforeEach(func() {
+		requests.Store(0)
+		remaining.Store(10)
+
+		server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			requests.Add(1)
+
+			res.Header().Set("RateLimit-Limit", "3600")
+			res.Header().Set("RateLimit-Remaining", fmt.Sprintf("%d", remaining.Load()))
+			res.Header().Set("RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+
+			if remaining.Load() <= 0 {
+				res.WriteHeader(http.StatusTooManyRequests)
+			} else {
+				res.WriteHeader(http.StatusOK)
+			}
+		}))
+
+		client = &http.Client{Transport: &rateLimitedTransport{base: http.DefaultTransport, limiter: &rateLimiter{}}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("#RoundTrip", func() {
+		It("should send requests within the budget immediately", func() {
+			for range 3 {
+				res, err := client.Get(server.URL)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res.StatusCode).To(Equal(http.StatusOK))
+				res.Body.Close()
+			}
+
+			Expect(requests.Load()).To(Equal(int32(3)))
+		})
+
+		It("should fail with a retry hint if the budget is exhausted before the deadline", func() {
+			remaining.Store(0)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("{}"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.Do(req)
+			Expect(err).To(HaveOccurred())
+
+			retryAfter, ok := GetRateLimitRetryAfter(err)
+			Expect(ok).To(BeTrue())
+			Expect(retryAfter).To(BeNumerically(">", 0))
+			Expect(requests.Load()).To(Equal(int32(1)))
+		})
+
+		It("should retry a rejected request once the budget is refilled", func() {
+			remaining.Store(0)
+
+			go func() {
+				defer GinkgoRecover()
+				Eventually(requests.Load).Should(Equal(int32(1)))
+				remaining.Store(1)
+			}()
+
+			res, err := client.Get(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			res.Body.Close()
+
+			Expect(requests.Load()).To(Equal(int32(2)))
+		})
+	})
+
+	Describe("#reserve", func() {
+		It("should space out requests after the budget has been used up", func() {
+			now := time.Now()
+			limiter := &rateLimiter{limit: 3600, remaining: 1, reset: now.Add(time.Hour), known: true}
+
+			Expect(limiter.reserve(now)).To(BeZero())
+			Expect(limiter.reserve(now)).To(BeNumerically("~", time.Second, time.Millisecond))
+			Expect(limiter.reserve(now)).To(BeNumerically("~", 2*time.Second, time.Millisecond))
+		})
+	})
+
+	Describe("#update", func() {
+		newHeader := func(remaining int, reset time.Time) http.Header {
+			header := http.Header{}
+			header.Set("RateLimit-Limit", "3600")
+			header.Set("RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+			header.Set("RateLimit-Reset", fmt.Sprintf("%d", reset.Unix()))
+
+			return header
+		}
+
+		It("should keep the smaller budget if the reset time is unchanged", func() {
+			reset := time.Now().Add(time.Hour)
+			limiter := &rateLimiter{}
+
+			limiter.update(newHeader(5, reset))
+			Expect(limiter.remaining).To(Equal(5))
+
+			limiter.reserve(time.Now())
+			limiter.reserve(time.Now())
+
+			limiter.update(newHeader(4, reset))
+			Expect(limiter.remaining).To(Equal(3))
+
+			limiter.update(newHeader(2, reset))
+			Expect(limiter.remaining).To(Equal(2))
+		})
+
+		It("should take the budget reported if the reset time changed", func() {
+			reset := time.Now().Add(time.Hour)
+			limiter := &rateLimiter{}
+
+			limiter.update(newHeader(1, reset))
+			limiter.update(newHeader(10, reset.Add(time.Minute)))
+
+			Expect(limiter.remaining).To(Equal(10))
+		})
+	})
+})
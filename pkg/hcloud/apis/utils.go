@@ -18,22 +18,203 @@ limitations under the License.
 package apis
 
 import (
+	"bytes"
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
-	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+	corev1 "k8s.io/api/core/v1"
 )
 
-// Constant defaultMachineOperationInterval is the time to wait between retries
-const defaultMachineOperationInterval = 5 * time.Second
+// Constant CSIDriverName is the name of the HCloud CSI driver
+const CSIDriverName = "csi.hetzner.cloud"
 
-// Constant defaultMachineOperationRetries is the maximum number of retries
-const defaultMachineOperationRetries = 5
+// Constant actionPollInitialInterval is the time to wait before polling running actions again
+const actionPollInitialInterval = 500 * time.Millisecond
+
+// Constant actionPollMaxInterval is the maximum time to wait between polling running actions
+const actionPollMaxInterval = 10 * time.Second
+
+// ActionWaitTimeout is the maximum time to wait for actions to complete
+var ActionWaitTimeout = 10 * time.Minute
+
+// DefaultShutdownTimeout is the time to wait for servers to shut down gracefully if no timeout is configured
+const DefaultShutdownTimeout = time.Minute
+
+// ActionError is returned if an action failed
+type ActionError struct {
+	ID      int
+	Command string
+	Code    string
+	Message string
+}
+
+// Error returns the error message
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("action %d (%s) failed: %s (%s)", e.ID, e.Command, e.Message, e.Code)
+}
+
+// attachToNetworkRequest is the request body of the "attach_to_network" server action supporting subnet IP ranges.
+type attachToNetworkRequest struct {
+	Network  int      `json:"network"`
+	IP       string   `json:"ip,omitempty"`
+	IPRange  string   `json:"ip_range,omitempty"`
+	AliasIPs []string `json:"alias_ips,omitempty"`
+}
+
+// AttachServerToNetwork attaches the server to the network as defined by the network spec given.
+//
+// PARAMETERS
+// ctx         context.Context Execution context
+// client      *hcloud.Client  HCloud client
+// server      *hcloud.Server  HCloud server struct
+// network     *hcloud.Network HCloud network struct
+// networkSpec *NetworkSpec    Network attachment spec
+func AttachServerToNetwork(ctx context.Context, client *hcloud.Client, server *hcloud.Server, network *hcloud.Network, networkSpec *NetworkSpec) (*hcloud.Action, error) {
+	reqBody := attachToNetworkRequest{
+		Network:  network.ID,
+		IP:       networkSpec.IP,
+		IPRange:  networkSpec.IPRange,
+		AliasIPs: networkSpec.AliasIPs,
+	}
+
+	reqBodyData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := client.NewRequest(ctx, "POST", fmt.Sprintf("/servers/%d/actions/attach_to_network", server.ID), bytes.NewReader(reqBodyData))
+	if err != nil {
+		return nil, err
+	}
+
+	var respBody schema.ServerActionAttachToNetworkResponse
+
+	_, err = client.Do(req, &respBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return hcloud.ActionFromSchema(respBody.Action), nil
+}
+
+// GetNetworkSpecs returns the network attachments defined by the provider spec.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider spec
+func GetNetworkSpecs(spec *ProviderSpec) []NetworkSpec {
+	if len(spec.Networks) == 0 && spec.NetworkName != "" {
+		return []NetworkSpec{{Name: spec.NetworkName}}
+	}
+
+	return spec.Networks
+}
+
+// IsNetworkAttachedAtCreation returns true if servers are attached to the network given at creation. Networks with
+// specific IP requirements are attached by InitializeMachine instead.
+//
+// PARAMETERS
+// networkSpec *NetworkSpec Network specification
+func IsNetworkAttachedAtCreation(networkSpec *NetworkSpec) bool {
+	return networkSpec.IP == "" && networkSpec.IPRange == "" && len(networkSpec.AliasIPs) == 0
+}
+
+// reservedLabels are labels set by the provider which must not be overridden by the provider spec
+var reservedLabels = []string{
+	"node.kubernetes.io/instance-type",
+	"topology.kubernetes.io/region",
+	"topology.kubernetes.io/zone",
+}
+
+// reservedLabelDomain is the label prefix domain reserved for labels set by the provider including its subdomains
+const reservedLabelDomain = "mcm.gardener.cloud"
+
+// IsReservedLabel returns true if the label key given is reserved for labels set by the provider.
+//
+// PARAMETERS
+// key string Label key
+func IsReservedLabel(key string) bool {
+	if slices.Contains(reservedLabels, key) {
+		return true
+	}
+
+	prefix, _, hasPrefix := strings.Cut(key, "/")
+
+	return hasPrefix && (prefix == reservedLabelDomain || strings.HasSuffix(prefix, "."+reservedLabelDomain))
+}
+
+// GetResourceLabels returns the labels of the provider spec merged with the labels given. Labels given take
+// precedence.
+//
+// PARAMETERS
+// spec   *ProviderSpec     Provider spec
+// labels map[string]string Labels set by the provider
+func GetResourceLabels(spec *ProviderSpec, labels map[string]string) map[string]string {
+	resourceLabels := make(map[string]string, len(spec.Labels)+len(labels))
+
+	for key, value := range spec.Labels {
+		resourceLabels[key] = value
+	}
+	for key, value := range labels {
+		resourceLabels[key] = value
+	}
+
+	return resourceLabels
+}
+
+// GetSSHKeySpecs returns the SSH keys defined by the provider spec.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider spec
+func GetSSHKeySpecs(spec *ProviderSpec) []SSHKeySpec {
+	if len(spec.SSHKeys) == 0 && spec.SSHFingerprint != "" {
+		return []SSHKeySpec{{Fingerprint: spec.SSHFingerprint}}
+	}
+
+	return spec.SSHKeys
+}
+
+// IsPublicIPv4Enabled returns true if the provider spec requests a public IPv4 address.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider spec
+func IsPublicIPv4Enabled(spec *ProviderSpec) bool {
+	return spec.PublicNet == nil || spec.PublicNet.EnableIPv4 == nil || *spec.PublicNet.EnableIPv4
+}
+
+// IsPublicIPv6Enabled returns true if the provider spec requests a public IPv6 network.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider spec
+func IsPublicIPv6Enabled(spec *ProviderSpec) bool {
+	return spec.PublicNet == nil || spec.PublicNet.EnableIPv6 == nil || *spec.PublicNet.EnableIPv6
+}
+
+// GetPlacementGroupSpec returns the placement group defined by the provider spec or nil.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider spec
+func GetPlacementGroupSpec(spec *ProviderSpec) *PlacementGroupSpec {
+	if spec.PlacementGroup == nil && spec.PlacementGroupID != "" {
+		placementGroupID, _ := strconv.Atoi(spec.PlacementGroupID)
+		return &PlacementGroupSpec{ID: placementGroupID}
+	}
+
+	if spec.PlacementGroup != nil && spec.PlacementGroup.AutoCreate && spec.PlacementGroup.Name == "" {
+		placementGroup := *spec.PlacementGroup
+		placementGroup.Name = fmt.Sprintf("%s-%s", spec.Cluster, spec.Zone)
+
+		return &placementGroup
+	}
+
+	return spec.PlacementGroup
+}
 
 // GetRegionFromZone returns the region for a given zone string
 //
@@ -44,51 +225,254 @@ func GetRegionFromZone(zone string) string {
 	return zoneData[0]
 }
 
-// waitForActionsOfRequest waits for all actions to complete.
+// GetImageSpec returns the image specification. The legacy ImageName field is converted if set.
 //
 // PARAMETERS
-// ctx    context.Context Execution context
-// client *hcloud.Client  HCloud client
-// req    *http.Request   Actions endpoint request to perform
-func waitForActionsOfRequest(ctx context.Context, client *hcloud.Client, req *http.Request) error {
-	var body schema.ActionListResponse
-	repeat := true
-	tryCount := 0
+// spec *ProviderSpec Provider specification
+func GetImageSpec(spec *ProviderSpec) *ImageSpec {
+	if spec.Image != nil {
+		return spec.Image
+	}
 
-	for repeat {
-		_, err := client.Do(req, &body)
-		if err != nil {
-			return err
+	return &ImageSpec{Name: spec.ImageName}
+}
+
+// GetServerTypes returns the server types to try in order of preference.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider specification
+func GetServerTypes(spec *ProviderSpec) []string {
+	return append([]string{spec.ServerType}, spec.ServerTypes...)
+}
+
+// GetZones returns the zones to try in order of preference.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider specification
+func GetZones(spec *ProviderSpec) []string {
+	return append([]string{spec.Zone}, spec.FallbackZones...)
+}
+
+// GetShutdownTimeout returns the time to wait for servers to shut down gracefully before they are powered off.
+//
+// PARAMETERS
+// spec *ProviderSpec Provider specification
+func GetShutdownTimeout(spec *ProviderSpec) (time.Duration, error) {
+	if spec.Shutdown == nil || spec.Shutdown.Timeout == "" {
+		return DefaultShutdownTimeout, nil
+	}
+
+	return time.ParseDuration(spec.Shutdown.Timeout)
+}
+
+// GetVolumeIDFromPVSpec returns the HCloud volume ID referenced by the given persistent volume spec. Only volumes of
+// the HCloud CSI driver are reported as they are the only ones tracked by node attachments and volume attachments.
+//
+// PARAMETERS
+// pvSpec *corev1.PersistentVolumeSpec Persistent volume spec
+func GetVolumeIDFromPVSpec(pvSpec *corev1.PersistentVolumeSpec) (string, bool) {
+	if pvSpec.CSI == nil || pvSpec.CSI.Driver != CSIDriverName {
+		return "", false
+	}
+
+	if _, err := strconv.Atoi(pvSpec.CSI.VolumeHandle); err != nil {
+		return "", false
+	}
+
+	return pvSpec.CSI.VolumeHandle, true
+}
+
+// GetVolumeName returns the name of the volume for the given machine based on the volume spec.
+//
+// PARAMETERS
+// volume      *VolumeSpec Volume spec
+// machineName string      Machine name
+// index       int         Index of the volume spec in the provider spec
+func GetVolumeName(volume *VolumeSpec, machineName string, index int) string {
+	if volume.Name == "" {
+		return fmt.Sprintf("%s-volume-%d", machineName, index)
+	}
+
+	return strings.ReplaceAll(volume.Name, VolumeNameMachinePlaceholder, machineName)
+}
+
+// WaitForActions waits for the actions given to complete. Actions are polled with exponential backoff until all of
+// them succeeded, one of them failed or the context is done. The wait is limited by ActionWaitTimeout.
+//
+// PARAMETERS
+// ctx     context.Context  Execution context
+// client  *hcloud.Client   HCloud client
+// actions ...*hcloud.Action Actions to wait for
+func WaitForActions(ctx context.Context, client *hcloud.Client, actions ...*hcloud.Action) error {
+	ctx, cancel := context.WithTimeout(ctx, ActionWaitTimeout)
+	defer cancel()
+
+	var pendingIDs []int
+
+	for _, action := range actions {
+		if action == nil {
+			continue
 		}
 
-		repeat = len(body.Actions) > 0
-		tryCount += 1
+		if action.Status == hcloud.ActionStatusError {
+			return &ActionError{ID: action.ID, Command: action.Command, Code: action.ErrorCode, Message: action.ErrorMessage}
+		} else if action.Status != hcloud.ActionStatusSuccess {
+			pendingIDs = append(pendingIDs, action.ID)
+		}
+	}
+
+	interval := actionPollInitialInterval
+
+	for len(pendingIDs) > 0 {
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("waiting for actions %v: %w", pendingIDs, ctx.Err())
+		case <-timer.C:
+		}
 
-		if repeat {
-			if tryCount > defaultMachineOperationRetries {
-				return errors.New("maximum number of retries exceeded waiting for actions")
+		var runningIDs []int
+
+		for _, id := range pendingIDs {
+			action, _, err := client.Action.GetByID(ctx, id)
+			if err != nil {
+				return err
+			} else if action == nil {
+				return fmt.Errorf("action %d not found", id)
+			}
+
+			if action.Status == hcloud.ActionStatusError {
+				return &ActionError{ID: action.ID, Command: action.Command, Code: action.ErrorCode, Message: action.ErrorMessage}
+			} else if action.Status != hcloud.ActionStatusSuccess {
+				runningIDs = append(runningIDs, id)
 			}
+		}
+
+		pendingIDs = runningIDs
 
-			time.Sleep(defaultMachineOperationInterval)
+		interval *= 2
+		if interval > actionPollMaxInterval {
+			interval = actionPollMaxInterval
 		}
 	}
 
 	return nil
 }
 
-// WaitForActionsAndGetFloatingIP waits for all actions to complete for the floating IP given and returns it afterwards.
+// waitForActionsOfResource waits for the actions given or all running actions of the resource to complete.
 //
 // PARAMETERS
-// ctx    context.Context    Execution context
-// client *hcloud.Client     HCloud client
-// ip     *hcloud.FloatingIP HCloud floating IP struct
-func WaitForActionsAndGetFloatingIP(ctx context.Context, client *hcloud.Client, ip *hcloud.FloatingIP) (*hcloud.FloatingIP, error) {
-	req, err := client.NewRequest(ctx, "GET", fmt.Sprintf("/floating_ips/%d/actions?status=running", ip.ID), nil)
+// ctx          context.Context  Execution context
+// client       *hcloud.Client   HCloud client
+// resourcePath string           API path of the resource
+// actions      ...*hcloud.Action Actions to wait for
+func waitForActionsOfResource(ctx context.Context, client *hcloud.Client, resourcePath string, actions ...*hcloud.Action) error {
+	for _, action := range actions {
+		if action != nil {
+			return WaitForActions(ctx, client, actions...)
+		}
+	}
+
+	runningActions, err := getRunningActionsOfResource(ctx, client, resourcePath)
+	if err != nil {
+		return err
+	}
+
+	return WaitForActions(ctx, client, runningActions...)
+}
+
+// GetRunningServerActions returns all running actions of the server given.
+//
+// PARAMETERS
+// ctx    context.Context Execution context
+// client *hcloud.Client  HCloud client
+// server *hcloud.Server  Server to return running actions for
+func GetRunningServerActions(ctx context.Context, client *hcloud.Client, server *hcloud.Server) ([]*hcloud.Action, error) {
+	return getRunningActionsOfResource(ctx, client, fmt.Sprintf("/servers/%d", server.ID))
+}
+
+// getRunningActionsOfResource returns all running actions of the resource given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// client       *hcloud.Client  HCloud client
+// resourcePath string          API path of the resource
+func getRunningActionsOfResource(ctx context.Context, client *hcloud.Client, resourcePath string) ([]*hcloud.Action, error) {
+	req, err := client.NewRequest(ctx, "GET", fmt.Sprintf("%s/actions?status=running", resourcePath), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body schema.ActionListResponse
+
+	_, err = client.Do(req, &body)
 	if err != nil {
 		return nil, err
 	}
 
-	err = waitForActionsOfRequest(ctx, client, req)
+	runningActions := make([]*hcloud.Action, 0, len(body.Actions))
+
+	for _, action := range body.Actions {
+		runningActions = append(runningActions, hcloud.ActionFromSchema(action))
+	}
+
+	return runningActions, nil
+}
+
+// WaitForServerStatus waits for the server given to reach the status given. The wait is limited by the timeout given.
+//
+// PARAMETERS
+// ctx     context.Context     Execution context
+// client  *hcloud.Client      HCloud client
+// server  *hcloud.Server      Server to wait for
+// status  hcloud.ServerStatus Server status to wait for
+// timeout time.Duration       Maximum time to wait
+func WaitForServerStatus(ctx context.Context, client *hcloud.Client, server *hcloud.Server, status hcloud.ServerStatus, timeout time.Duration) (*hcloud.Server, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interval := actionPollInitialInterval
+
+	for server.Status != status {
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return server, fmt.Errorf("waiting for server %d to be %s: %w", server.ID, status, ctx.Err())
+		case <-timer.C:
+		}
+
+		updatedServer, _, err := client.Server.GetByID(ctx, server.ID)
+		if err != nil {
+			return server, err
+		} else if updatedServer == nil {
+			return server, fmt.Errorf("server %d not found", server.ID)
+		}
+
+		server = updatedServer
+
+		interval *= 2
+		if interval > actionPollMaxInterval {
+			interval = actionPollMaxInterval
+		}
+	}
+
+	return server, nil
+}
+
+// WaitForActionsAndGetFloatingIP waits for the actions given or all running actions of the floating IP to complete
+// and returns it afterwards.
+//
+// PARAMETERS
+// ctx     context.Context    Execution context
+// client  *hcloud.Client     HCloud client
+// ip      *hcloud.FloatingIP HCloud floating IP struct
+// actions ...*hcloud.Action   Actions to wait for
+func WaitForActionsAndGetFloatingIP(ctx context.Context, client *hcloud.Client, ip *hcloud.FloatingIP, actions ...*hcloud.Action) (*hcloud.FloatingIP, error) {
+	err := waitForActionsOfResource(ctx, client, fmt.Sprintf("/floating_ips/%d", ip.ID), actions...)
 	if nil != err {
 		return nil, err
 	}
@@ -101,19 +485,38 @@ func WaitForActionsAndGetFloatingIP(ctx context.Context, client *hcloud.Client,
 	return ip, nil
 }
 
-// WaitForActionsAndGetServer waits for all actions to complete for the server given and returns it afterwards.
+// WaitForActionsAndGetVolume waits for the actions given or all running actions of the volume to complete and
+// returns it afterwards.
 //
 // PARAMETERS
-// ctx    context.Context Execution context
-// client *hcloud.Client  HCloud client
-// ip     *hcloud.Server  HCloud server struct
-func WaitForActionsAndGetServer(ctx context.Context, client *hcloud.Client, server *hcloud.Server) (*hcloud.Server, error) {
-	req, err := client.NewRequest(ctx, "GET", fmt.Sprintf("/servers/%d/actions?status=running", server.ID), nil)
-	if err != nil {
+// ctx     context.Context  Execution context
+// client  *hcloud.Client   HCloud client
+// volume  *hcloud.Volume   HCloud volume struct
+// actions ...*hcloud.Action Actions to wait for
+func WaitForActionsAndGetVolume(ctx context.Context, client *hcloud.Client, volume *hcloud.Volume, actions ...*hcloud.Action) (*hcloud.Volume, error) {
+	err := waitForActionsOfResource(ctx, client, fmt.Sprintf("/volumes/%d", volume.ID), actions...)
+	if nil != err {
 		return nil, err
 	}
 
-	err = waitForActionsOfRequest(ctx, client, req)
+	volume, _, err = client.Volume.GetByID(ctx, volume.ID)
+	if nil != err {
+		return nil, err
+	}
+
+	return volume, nil
+}
+
+// WaitForActionsAndGetServer waits for the actions given or all running actions of the server to complete and
+// returns it afterwards.
+//
+// PARAMETERS
+// ctx     context.Context  Execution context
+// client  *hcloud.Client   HCloud client
+// server  *hcloud.Server   HCloud server struct
+// actions ...*hcloud.Action Actions to wait for
+func WaitForActionsAndGetServer(ctx context.Context, client *hcloud.Client, server *hcloud.Server, actions ...*hcloud.Action) (*hcloud.Server, error) {
+	err := waitForActionsOfResource(ctx, client, fmt.Sprintf("/servers/%d", server.ID), actions...)
 	if nil != err {
 		return nil, err
 	}
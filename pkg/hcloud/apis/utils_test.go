@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Utils", func() {
+	var (
+		polls  atomic.Int32
+		server *httptest.Server
+		client *hcloud.Client
+	)
+
+	BeforeEach(func() {
+		polls.Store(0)
+
+		server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+			actionStatus := "running"
+			actionError := "null"
+
+			switch req.URL.Path {
+			case "/actions/1":
+				if polls.Add(1) > 1 {
+					actionStatus = "success"
+				}
+			case "/actions/2":
+				actionStatus = "error"
+				actionError = `{ "code": "action_failed", "message": "Action failed" }`
+			}
+
+			res.WriteHeader(http.StatusOK)
+			fmt.Fprintf(res, `{ "action": { "id": 1, "command": "test", "status": "%s", "progress": 0, "started": "2016-01-30T23:50:00+00:00", "finished": null, "resources": [], "error": %s } }`, actionStatus, actionError)
+		}))
+
+		client = hcloud.NewClient(hcloud.WithEndpoint(server.URL), hcloud.WithToken("dummy-token"))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("#WaitForActions", func() {
+		It("should return once all actions succeeded", func() {
+			Expect(WaitForActions(context.Background(), client, nil, &hcloud.Action{ID: 1, Status: hcloud.ActionStatusRunning})).To(Succeed())
+			Expect(polls.Load()).To(Equal(int32(2)))
+		})
+
+		It("should report the error of a failed action", func() {
+			err := WaitForActions(context.Background(), client, &hcloud.Action{ID: 2, Status: hcloud.ActionStatusRunning})
+
+			var actionErr *ActionError
+			Expect(errors.As(err, &actionErr)).To(BeTrue())
+			Expect(actionErr.Code).To(Equal("action_failed"))
+			Expect(actionErr.Message).To(Equal("Action failed"))
+		})
+
+		It("should stop waiting once the context is done", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err := WaitForActions(ctx, client, &hcloud.Action{ID: 3, Status: hcloud.ActionStatusRunning})
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+	})
+})
@@ -19,8 +19,16 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hetznercloud/hcloud-go/hcloud"
 	corev1 "k8s.io/api/core/v1"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis"
 )
@@ -36,19 +44,400 @@ func ValidateHCloudProviderSpec(spec *apis.ProviderSpec, secrets *corev1.Secret)
 	if spec.Cluster == "" {
 		allErrs = append(allErrs, fmt.Errorf("cluster is a required field"))
 	}
+	allErrs = append(allErrs, validateLabels(spec.Labels)...)
+	allErrs = append(allErrs, validateNodeTemplateLabels(spec.NodeTemplateLabels)...)
 	if spec.Zone == "" {
 		allErrs = append(allErrs, fmt.Errorf("zone is a required field"))
 	}
-	if spec.ImageName == "" {
+	if spec.ImageName != "" && spec.Image != nil {
+		allErrs = append(allErrs, fmt.Errorf("imageName and image are mutually exclusive"))
+	} else if spec.Image != nil {
+		allErrs = append(allErrs, validateImageSpec(spec.Image)...)
+	} else if spec.ImageName == "" {
 		allErrs = append(allErrs, fmt.Errorf("imageName is a required field"))
 	}
 	if spec.ServerType == "" {
 		allErrs = append(allErrs, fmt.Errorf("serverType is a required field"))
 	}
-	if spec.SSHFingerprint == "" {
-		allErrs = append(allErrs, fmt.Errorf("sshFingerprint is a required field"))
+	allErrs = append(allErrs, validateFallbacks(spec)...)
+	if spec.SSHFingerprint != "" && len(spec.SSHKeys) > 0 {
+		allErrs = append(allErrs, fmt.Errorf("sshFingerprint and sshKeys are mutually exclusive"))
+	} else if spec.SSHFingerprint == "" && len(spec.SSHKeys) == 0 && !spec.SSHKeysOptional {
+		allErrs = append(allErrs, fmt.Errorf("sshKeys is a required field unless sshKeysOptional is set"))
+	}
+	for index, sshKey := range spec.SSHKeys {
+		allErrs = append(allErrs, validateSSHKeySpec(&sshKey, index)...)
+	}
+	if spec.PlacementGroupID != "" {
+		if spec.PlacementGroup != nil {
+			allErrs = append(allErrs, fmt.Errorf("placementGroupID and placementGroup are mutually exclusive"))
+		}
+		if _, err := strconv.Atoi(spec.PlacementGroupID); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("placementGroupID %q is not a valid ID", spec.PlacementGroupID))
+		}
+	}
+	if spec.PlacementGroup != nil {
+		allErrs = append(allErrs, validatePlacementGroupSpec(spec.PlacementGroup)...)
+	}
+	if spec.NetworkName != "" && len(spec.Networks) > 0 {
+		allErrs = append(allErrs, fmt.Errorf("networkName and networks are mutually exclusive"))
+	}
+
+	networkNames := make(map[string]bool)
+
+	for index, network := range spec.Networks {
+		allErrs = append(allErrs, validateNetworkSpec(&network, index)...)
+
+		if networkNames[network.Name] {
+			allErrs = append(allErrs, fmt.Errorf("networks[%d].name %q is not unique", index, network.Name))
+		}
+
+		networkNames[network.Name] = true
+	}
+	for index, volume := range spec.Volumes {
+		allErrs = append(allErrs, validateVolumeSpec(&volume, index)...)
+	}
+	for index, firewall := range spec.Firewalls {
+		allErrs = append(allErrs, validateFirewallSpec(&firewall, index)...)
+	}
+	if spec.PublicNet != nil {
+		allErrs = append(allErrs, validatePublicNetSpec(spec)...)
+	}
+	if spec.Preflight != nil {
+		if spec.Preflight.MaxServers < 0 {
+			allErrs = append(allErrs, fmt.Errorf("preflight.maxServers must not be negative"))
+		}
+		if spec.Preflight.MaxCores < 0 {
+			allErrs = append(allErrs, fmt.Errorf("preflight.maxCores must not be negative"))
+		}
+	}
+	if spec.Shutdown != nil && spec.Shutdown.Timeout != "" {
+		if timeout, err := time.ParseDuration(spec.Shutdown.Timeout); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("shutdown.timeout %q is not a valid duration", spec.Shutdown.Timeout))
+		} else if timeout <= 0 {
+			allErrs = append(allErrs, fmt.Errorf("shutdown.timeout must be positive"))
+		}
+	}
+	if spec.Protection != nil && spec.Protection.Delete != spec.Protection.Rebuild {
+		allErrs = append(allErrs, fmt.Errorf("protection.delete and protection.rebuild must have the same value"))
 	}
 	//allErrs = append(allErrs, ValidateSecret(secret)...)
 
 	return allErrs
 }
+
+// validateLabels validates the given labels against the HCloud label syntax and rejects labels reserved for the
+// provider
+//
+// PARAMETERS
+// labels map[string]string Labels to validate
+func validateLabels(labels map[string]string) []error {
+	var allErrs []error
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if errs := k8svalidation.IsQualifiedName(key); len(errs) > 0 {
+			allErrs = append(allErrs, fmt.Errorf("labels key %q is invalid: %s", key, strings.Join(errs, "; ")))
+		} else if apis.IsReservedLabel(key) {
+			allErrs = append(allErrs, fmt.Errorf("labels key %q is reserved", key))
+		}
+		if errs := k8svalidation.IsValidLabelValue(labels[key]); len(errs) > 0 {
+			allErrs = append(allErrs, fmt.Errorf("labels value %q of %q is invalid: %s", labels[key], key, strings.Join(errs, "; ")))
+		}
+	}
+
+	return allErrs
+}
+
+// validateNodeTemplateLabels validates the given node template label keys to be added to servers
+//
+// PARAMETERS
+// keys []string Node template label keys to validate
+func validateNodeTemplateLabels(keys []string) []error {
+	var allErrs []error
+
+	knownKeys := make(map[string]bool)
+
+	for index, key := range keys {
+		if errs := k8svalidation.IsQualifiedName(key); len(errs) > 0 {
+			allErrs = append(allErrs, fmt.Errorf("nodeTemplateLabels[%d] %q is invalid: %s", index, key, strings.Join(errs, "; ")))
+		} else if apis.IsReservedLabel(key) {
+			allErrs = append(allErrs, fmt.Errorf("nodeTemplateLabels[%d] %q is reserved", index, key))
+		} else if knownKeys[key] {
+			allErrs = append(allErrs, fmt.Errorf("nodeTemplateLabels[%d] %q is not unique", index, key))
+		}
+
+		knownKeys[key] = true
+	}
+
+	return allErrs
+}
+
+// validateImageSpec validates the given image specification
+//
+// PARAMETERS
+// image *apis.ImageSpec Image specification to validate
+func validateImageSpec(image *apis.ImageSpec) []error {
+	var allErrs []error
+
+	references := 0
+
+	if image.ID != 0 {
+		references++
+	}
+	if image.Name != "" {
+		references++
+	}
+	if image.LabelSelector != "" {
+		references++
+	}
+
+	if references == 0 {
+		allErrs = append(allErrs, fmt.Errorf("one of image.id, image.name or image.labelSelector is required"))
+	} else if references > 1 {
+		allErrs = append(allErrs, fmt.Errorf("image.id, image.name and image.labelSelector are mutually exclusive"))
+	}
+	if image.ID < 0 {
+		allErrs = append(allErrs, fmt.Errorf("image.id must be positive"))
+	}
+	if image.Architecture != "" && image.Architecture != string(hcloud.ArchitectureX86) && image.Architecture != string(hcloud.ArchitectureARM) {
+		allErrs = append(allErrs, fmt.Errorf("image.architecture %q is not supported", image.Architecture))
+	}
+
+	return allErrs
+}
+
+// validateFallbacks validates the fallback server types and zones of the given provider specification
+//
+// PARAMETERS
+// spec *apis.ProviderSpec Provider specification to validate
+func validateFallbacks(spec *apis.ProviderSpec) []error {
+	var allErrs []error
+
+	serverTypes := map[string]bool{spec.ServerType: true}
+
+	for index, serverType := range spec.ServerTypes {
+		if serverType == "" {
+			allErrs = append(allErrs, fmt.Errorf("serverTypes[%d] must not be empty", index))
+		} else if serverTypes[serverType] {
+			allErrs = append(allErrs, fmt.Errorf("serverTypes[%d] %q is not unique", index, serverType))
+		}
+
+		serverTypes[serverType] = true
+	}
+
+	region := apis.GetRegionFromZone(spec.Zone)
+	zones := map[string]bool{spec.Zone: true}
+
+	for index, zone := range spec.FallbackZones {
+		if zone == "" {
+			allErrs = append(allErrs, fmt.Errorf("fallbackZones[%d] must not be empty", index))
+		} else if zones[zone] {
+			allErrs = append(allErrs, fmt.Errorf("fallbackZones[%d] %q is not unique", index, zone))
+		} else if apis.GetRegionFromZone(zone) != region {
+			allErrs = append(allErrs, fmt.Errorf("fallbackZones[%d] %q is not in region %s", index, zone, region))
+		}
+
+		zones[zone] = true
+	}
+
+	return allErrs
+}
+
+// validateVolumeSpec validates the given volume specification
+//
+// PARAMETERS
+// volume *apis.VolumeSpec Volume specification to validate
+// index  int              Index of the volume specification
+func validateVolumeSpec(volume *apis.VolumeSpec, index int) []error {
+	var allErrs []error
+
+	if volume.Name != "" && !strings.Contains(volume.Name, apis.VolumeNameMachinePlaceholder) {
+		allErrs = append(allErrs, fmt.Errorf("volumes[%d].name must contain %s", index, apis.VolumeNameMachinePlaceholder))
+	}
+	if volume.Size < 10 {
+		allErrs = append(allErrs, fmt.Errorf("volumes[%d].size must be at least 10", index))
+	}
+	if volume.Format != "" && volume.Format != hcloud.VolumeFormatExt4 && volume.Format != hcloud.VolumeFormatXFS {
+		allErrs = append(allErrs, fmt.Errorf("volumes[%d].format %q is not supported", index, volume.Format))
+	}
+	if volume.Automount && volume.Format == "" {
+		allErrs = append(allErrs, fmt.Errorf("volumes[%d].format is required for automount", index))
+	}
+	if volume.DeletionPolicy != "" && volume.DeletionPolicy != apis.DeletionPolicyDelete && volume.DeletionPolicy != apis.DeletionPolicyRetain {
+		allErrs = append(allErrs, fmt.Errorf("volumes[%d].deletionPolicy %q is not supported", index, volume.DeletionPolicy))
+	}
+
+	return allErrs
+}
+
+// validatePlacementGroupSpec validates the given placement group specification
+//
+// PARAMETERS
+// placementGroup *apis.PlacementGroupSpec Placement group specification to validate
+func validatePlacementGroupSpec(placementGroup *apis.PlacementGroupSpec) []error {
+	var allErrs []error
+
+	if placementGroup.ID != 0 && (placementGroup.Name != "" || placementGroup.AutoCreate) {
+		allErrs = append(allErrs, fmt.Errorf("placementGroup.id is mutually exclusive with placementGroup.name and placementGroup.autoCreate"))
+	}
+	if placementGroup.ID == 0 && placementGroup.Name == "" && !placementGroup.AutoCreate {
+		allErrs = append(allErrs, fmt.Errorf("placementGroup must define id, name or autoCreate"))
+	}
+	if placementGroup.Type != "" && placementGroup.Type != string(hcloud.PlacementGroupTypeSpread) {
+		allErrs = append(allErrs, fmt.Errorf("placementGroup.type %q is not supported", placementGroup.Type))
+	}
+
+	return allErrs
+}
+
+// validateNetworkSpec validates the given network specification
+//
+// PARAMETERS
+// network *apis.NetworkSpec Network specification to validate
+// index   int               Index of the network specification
+func validateNetworkSpec(network *apis.NetworkSpec, index int) []error {
+	var allErrs []error
+
+	if network.Name == "" {
+		allErrs = append(allErrs, fmt.Errorf("networks[%d].name is a required field", index))
+	}
+	if network.IP != "" && network.IPRange != "" {
+		allErrs = append(allErrs, fmt.Errorf("networks[%d].ip and networks[%d].ipRange are mutually exclusive", index, index))
+	}
+	if network.IP != "" && net.ParseIP(network.IP) == nil {
+		allErrs = append(allErrs, fmt.Errorf("networks[%d].ip %q is not a valid IP", index, network.IP))
+	}
+	if network.IPRange != "" {
+		if _, _, err := net.ParseCIDR(network.IPRange); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("networks[%d].ipRange %q is not a valid CIDR", index, network.IPRange))
+		}
+	}
+	for aliasIndex, aliasIP := range network.AliasIPs {
+		if net.ParseIP(aliasIP) == nil {
+			allErrs = append(allErrs, fmt.Errorf("networks[%d].aliasIPs[%d] %q is not a valid IP", index, aliasIndex, aliasIP))
+		}
+	}
+
+	return allErrs
+}
+
+// validateFirewallSpec validates the given firewall specification
+//
+// PARAMETERS
+// firewall *apis.FirewallSpec Firewall specification to validate
+// index    int                Index of the firewall specification
+func validateFirewallSpec(firewall *apis.FirewallSpec, index int) []error {
+	var allErrs []error
+
+	references := 0
+
+	if firewall.ID != 0 {
+		references++
+	}
+	if firewall.Name != "" {
+		references++
+	}
+	if firewall.LabelSelector != "" {
+		references++
+	}
+
+	if references != 1 {
+		allErrs = append(allErrs, fmt.Errorf("firewalls[%d] must define exactly one of id, name or labelSelector", index))
+	}
+
+	return allErrs
+}
+
+// validateSSHKeySpec validates the given SSH key specification
+//
+// PARAMETERS
+// sshKey *apis.SSHKeySpec SSH key specification to validate
+// index  int              Index of the SSH key specification
+func validateSSHKeySpec(sshKey *apis.SSHKeySpec, index int) []error {
+	var allErrs []error
+
+	references := 0
+
+	if sshKey.Fingerprint != "" {
+		references++
+	}
+	if sshKey.Name != "" {
+		references++
+	}
+	if sshKey.LabelSelector != "" {
+		references++
+	}
+
+	if references != 1 {
+		allErrs = append(allErrs, fmt.Errorf("sshKeys[%d] must define exactly one of fingerprint, name or labelSelector", index))
+	}
+
+	return allErrs
+}
+
+// validatePublicNetSpec validates the public network specification of the given provider specification
+//
+// PARAMETERS
+// spec *apis.ProviderSpec Provider specification to validate
+func validatePublicNetSpec(spec *apis.ProviderSpec) []error {
+	var allErrs []error
+
+	isIPv4Enabled := apis.IsPublicIPv4Enabled(spec)
+	isIPv6Enabled := apis.IsPublicIPv6Enabled(spec)
+
+	if !isIPv4Enabled && !isIPv6Enabled {
+		networkSpecs := apis.GetNetworkSpecs(spec)
+
+		if len(networkSpecs) == 0 {
+			allErrs = append(allErrs, fmt.Errorf("publicNet without IPv4 and IPv6 requires a private network"))
+		} else if !slices.ContainsFunc(networkSpecs, func(networkSpec apis.NetworkSpec) bool {
+			return apis.IsNetworkAttachedAtCreation(&networkSpec)
+		}) {
+			allErrs = append(allErrs, fmt.Errorf("publicNet without IPv4 and IPv6 requires a private network without ip, ipRange and aliasIPs to attach servers to at creation"))
+		}
+		if len(spec.Firewalls) > 0 {
+			allErrs = append(allErrs, fmt.Errorf("firewalls require publicNet with IPv4 or IPv6"))
+		}
+	}
+
+	if spec.PublicNet.IPv4Pool != nil {
+		if !isIPv4Enabled {
+			allErrs = append(allErrs, fmt.Errorf("publicNet.ipv4Pool requires publicNet.enableIPv4"))
+		}
+		allErrs = append(allErrs, validatePrimaryIPPoolSpec(spec.PublicNet.IPv4Pool, "ipv4Pool")...)
+	}
+
+	if spec.PublicNet.IPv6Pool != nil {
+		if !isIPv6Enabled {
+			allErrs = append(allErrs, fmt.Errorf("publicNet.ipv6Pool requires publicNet.enableIPv6"))
+		}
+		allErrs = append(allErrs, validatePrimaryIPPoolSpec(spec.PublicNet.IPv6Pool, "ipv6Pool")...)
+	}
+
+	return allErrs
+}
+
+// validatePrimaryIPPoolSpec validates the given primary IP pool specification
+//
+// PARAMETERS
+// pool  *apis.PrimaryIPPoolSpec Primary IP pool specification to validate
+// field string                  Field name of the pool specification
+func validatePrimaryIPPoolSpec(pool *apis.PrimaryIPPoolSpec, field string) []error {
+	var allErrs []error
+
+	if pool.LabelSelector == "" {
+		allErrs = append(allErrs, fmt.Errorf("publicNet.%s.labelSelector is a required field", field))
+	}
+	if pool.DeletionPolicy != "" && pool.DeletionPolicy != apis.DeletionPolicyDelete && pool.DeletionPolicy != apis.DeletionPolicyRetain {
+		allErrs = append(allErrs, fmt.Errorf("publicNet.%s.deletionPolicy %q is not supported", field, pool.DeletionPolicy))
+	}
+
+	return allErrs
+}
@@ -29,6 +29,7 @@ import (
 )
 
 var _ = Describe("Validation", func() {
+	disabled := false
 	providerSecret := &corev1.Secret{
 		Data: map[string][]byte{
 			"userData": []byte("dummy-user-data"),
@@ -149,6 +150,94 @@ var _ = Describe("Validation", func() {
 					},
 				},
 			}),
+			Entry("labels with invalid or reserved keys and values", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Labels": map[string]string{
+							"team":                        "a",
+							"-invalid":                    "a",
+							"environment":                 "production!",
+							"mcm.gardener.cloud/role":     "worker",
+							"topology.kubernetes.io/zone": "fsn1-dc14",
+							"storage.hcloud.mcm.gardener.cloud/machine": "test",
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("labels key \"-invalid\" is invalid: name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')"),
+						fmt.Errorf("labels value \"production!\" of \"environment\" is invalid: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')"),
+						fmt.Errorf("labels key \"mcm.gardener.cloud/role\" is reserved"),
+						fmt.Errorf("labels key \"storage.hcloud.mcm.gardener.cloud/machine\" is reserved"),
+						fmt.Errorf("labels key \"topology.kubernetes.io/zone\" is reserved"),
+					},
+				},
+			}),
+			Entry("node template labels with invalid, reserved or duplicated keys", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"NodeTemplateLabels": []string{"worker.gardener.cloud/pool", "", "node.kubernetes.io/instance-type", "worker.gardener.cloud/pool"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("nodeTemplateLabels[1] \"\" is invalid: name part must be non-empty; name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')"),
+						fmt.Errorf("nodeTemplateLabels[2] \"node.kubernetes.io/instance-type\" is reserved"),
+						fmt.Errorf("nodeTemplateLabels[3] \"worker.gardener.cloud/pool\" is not unique"),
+					},
+				},
+			}),
+			Entry("shutdown with invalid timeout", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Shutdown": &apis.ShutdownSpec{Timeout: "1 minute"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("shutdown.timeout \"1 minute\" is not a valid duration"),
+					},
+				},
+			}),
+			Entry("shutdown with negative timeout", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Shutdown": &apis.ShutdownSpec{Timeout: "-1m"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("shutdown.timeout must be positive"),
+					},
+				},
+			}),
+			Entry("protection with different delete and rebuild values", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Protection": &apis.ProtectionSpec{Delete: true},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("protection.delete and protection.rebuild must have the same value"),
+					},
+				},
+			}),
 			Entry("sshFingerprint field missing", &data{
 				setup: setup{},
 				action: action{
@@ -163,7 +252,360 @@ var _ = Describe("Validation", func() {
 				expect: expect{
 					errToHaveOccurred: true,
 					errList: []error{
-						fmt.Errorf("sshFingerprint is a required field"),
+						fmt.Errorf("sshKeys is a required field unless sshKeysOptional is set"),
+					},
+				},
+			}),
+			Entry("SSH keys optional", &data{
+				setup: setup{},
+				action: action{
+					spec: &apis.ProviderSpec{
+						Cluster:         mock.TestCluster,
+						Zone:            mock.TestZone,
+						ImageName:       mock.TestImageName,
+						ServerType:      mock.TestServerType,
+						SSHKeysOptional: true,
+					},
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("sshFingerprint and sshKeys", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"SSHKeys": []apis.SSHKeySpec{{Name: "test-key"}},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("sshFingerprint and sshKeys are mutually exclusive"),
+					},
+				},
+			}),
+			Entry("SSH key with multiple references", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"SSHFingerprint": "",
+						"SSHKeys": []apis.SSHKeySpec{
+							{Fingerprint: mock.TestSSHFingerprint, Name: "test-key"},
+							{},
+							{LabelSelector: "team=a"},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("sshKeys[0] must define exactly one of fingerprint, name or labelSelector"),
+						fmt.Errorf("sshKeys[1] must define exactly one of fingerprint, name or labelSelector"),
+					},
+				},
+			}),
+			Entry("volume with invalid fields", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Volumes": []apis.VolumeSpec{
+							{
+								Name:           "data",
+								Size:           5,
+								Format:         "btrfs",
+								DeletionPolicy: "Orphan",
+							},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("volumes[0].name must contain {machine}"),
+						fmt.Errorf("volumes[0].size must be at least 10"),
+						fmt.Errorf("volumes[0].format \"btrfs\" is not supported"),
+						fmt.Errorf("volumes[0].deletionPolicy \"Orphan\" is not supported"),
+					},
+				},
+			}),
+			Entry("volume with automount but without format", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Volumes": []apis.VolumeSpec{
+							{
+								Name:      "{machine}-data",
+								Size:      10,
+								Automount: true,
+							},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("volumes[0].format is required for automount"),
+					},
+				},
+			}),
+			Entry("firewall with multiple references", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Firewalls": []apis.FirewallSpec{
+							{ID: 42, Name: mock.TestFirewallName},
+							{},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("firewalls[0] must define exactly one of id, name or labelSelector"),
+						fmt.Errorf("firewalls[1] must define exactly one of id, name or labelSelector"),
+					},
+				},
+			}),
+			Entry("networks with invalid fields", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"NetworkName": mock.TestNetworkName,
+						"Networks": []apis.NetworkSpec{
+							{
+								Name:     mock.TestNetworkName,
+								IP:       "10.0.0.300",
+								IPRange:  "10.0.0.0/33",
+								AliasIPs: []string{"invalid"},
+							},
+							{
+								Name: mock.TestNetworkName,
+							},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("networkName and networks are mutually exclusive"),
+						fmt.Errorf("networks[0].ip and networks[0].ipRange are mutually exclusive"),
+						fmt.Errorf("networks[0].ip \"10.0.0.300\" is not a valid IP"),
+						fmt.Errorf("networks[0].ipRange \"10.0.0.0/33\" is not a valid CIDR"),
+						fmt.Errorf("networks[0].aliasIPs[0] \"invalid\" is not a valid IP"),
+						fmt.Errorf("networks[1].name \"test-network\" is not unique"),
+					},
+				},
+			}),
+			Entry("publicNet with invalid fields", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Firewalls": []apis.FirewallSpec{
+							{Name: mock.TestFirewallName},
+						},
+						"PublicNet": &apis.PublicNetSpec{
+							EnableIPv4: &disabled,
+							EnableIPv6: &disabled,
+							IPv4Pool: &apis.PrimaryIPPoolSpec{
+								DeletionPolicy: "Orphan",
+							},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("publicNet without IPv4 and IPv6 requires a private network"),
+						fmt.Errorf("firewalls require publicNet with IPv4 or IPv6"),
+						fmt.Errorf("publicNet.ipv4Pool requires publicNet.enableIPv4"),
+						fmt.Errorf("publicNet.ipv4Pool.labelSelector is a required field"),
+						fmt.Errorf("publicNet.ipv4Pool.deletionPolicy \"Orphan\" is not supported"),
+					},
+				},
+			}),
+			Entry("private-only publicNet with networks attached by InitializeMachine only", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Networks": []apis.NetworkSpec{
+							{Name: mock.TestNetworkName, IP: "10.0.0.5"},
+							{Name: "test-network-2", IPRange: "10.1.0.0/24"},
+							{Name: "test-network-3", AliasIPs: []string{"10.2.0.5"}},
+						},
+						"PublicNet": &apis.PublicNetSpec{
+							EnableIPv4: &disabled,
+							EnableIPv6: &disabled,
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("publicNet without IPv4 and IPv6 requires a private network without ip, ipRange and aliasIPs to attach servers to at creation"),
+					},
+				},
+			}),
+			Entry("private-only publicNet with a network", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Networks": []apis.NetworkSpec{
+							{Name: mock.TestNetworkName},
+						},
+						"PublicNet": &apis.PublicNetSpec{
+							EnableIPv4: &disabled,
+							EnableIPv6: &disabled,
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("placement group with invalid fields", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"PlacementGroupID": "invalid",
+						"PlacementGroup": &apis.PlacementGroupSpec{
+							ID:         42,
+							AutoCreate: true,
+							Type:       "cluster",
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("placementGroupID and placementGroup are mutually exclusive"),
+						fmt.Errorf("placementGroupID \"invalid\" is not a valid ID"),
+						fmt.Errorf("placementGroup.id is mutually exclusive with placementGroup.name and placementGroup.autoCreate"),
+						fmt.Errorf("placementGroup.type \"cluster\" is not supported"),
+					},
+				},
+			}),
+			Entry("fallbacks with invalid fields", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ServerTypes":   []string{"cx21", "", mock.TestServerType},
+						"FallbackZones": []string{"hel1-dc3", mock.TestZone, "fsn1-dc14", ""},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("serverTypes[1] must not be empty"),
+						fmt.Errorf("serverTypes[2] \"cx11-ceph\" is not unique"),
+						fmt.Errorf("fallbackZones[1] \"hel1-dc2\" is not unique"),
+						fmt.Errorf("fallbackZones[2] \"fsn1-dc14\" is not in region hel1"),
+						fmt.Errorf("fallbackZones[3] must not be empty"),
+					},
+				},
+			}),
+			Entry("preflight with negative limits", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Preflight": &apis.PreflightSpec{MaxServers: -1, MaxCores: -1},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("preflight.maxServers must not be negative"),
+						fmt.Errorf("preflight.maxCores must not be negative"),
+					},
+				},
+			}),
+			Entry("image with imageName", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Image": &apis.ImageSpec{ID: 42},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("imageName and image are mutually exclusive"),
+					},
+				},
+			}),
+			Entry("image with invalid fields", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageName": "",
+						"Image":     &apis.ImageSpec{ID: -1, LabelSelector: "os=gardenlinux", Architecture: "mips"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("image.id, image.name and image.labelSelector are mutually exclusive"),
+						fmt.Errorf("image.id must be positive"),
+						fmt.Errorf("image.architecture \"mips\" is not supported"),
+					},
+				},
+			}),
+			Entry("image architecture not derived from server type names", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageName":   "",
+						"Image":       &apis.ImageSpec{Name: "ubuntu-22.04", Architecture: "arm"},
+						"ServerTypes": []string{mock.TestArmServerType},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("image architecture of ARM server types", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageName":  "",
+						"Image":      &apis.ImageSpec{Name: "ubuntu-22.04", Architecture: "arm"},
+						"ServerType": mock.TestArmServerType,
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("image without reference", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageName": "",
+						"Image":     &apis.ImageSpec{Architecture: "x86"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("one of image.id, image.name or image.labelSelector is required"),
 					},
 				},
 			}),
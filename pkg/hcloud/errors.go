@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hcloud contains the HCloud provider specific implementations to manage machines
+package hcloud
+
+import (
+	"errors"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+
+	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis"
+)
+
+const (
+	// errorCodeServerTypeUnavailable is returned if the server type requested is unavailable in the datacenter
+	errorCodeServerTypeUnavailable = hcloud.ErrorCode("server_type_unavailable")
+	// errorCodeTimeout is returned for actions not completed in time
+	errorCodeTimeout = hcloud.ErrorCode("timeout")
+)
+
+// hcloudErrorCodes maps HCloud API and action error codes to the machine codes MCM bases its retry and
+// delete-on-failure decisions on.
+var hcloudErrorCodes = map[hcloud.ErrorCode]codes.Code{
+	// Missing capacity or exceeded limits are retried with a long backoff
+	hcloud.ErrorCodeRateLimitExceeded:     codes.ResourceExhausted,
+	hcloud.ErrorCodeResourceLimitExceeded: codes.ResourceExhausted,
+	hcloud.ErrorCodeResourceUnavailable:   codes.ResourceExhausted,
+	errorCodeServerTypeUnavailable:        codes.ResourceExhausted,
+	hcloud.ErrorCodeNoSpaceLeftInLocation: codes.ResourceExhausted,
+	hcloud.ErrorCodeIPNotAvailable:        codes.ResourceExhausted,
+	hcloud.ErrorCodeNoSubnetAvailable:     codes.ResourceExhausted,
+	hcloud.ErrorCodePlacementError:        codes.ResourceExhausted,
+
+	// Invalid requests will not succeed without a change of the machine class
+	hcloud.ErrorCodeInvalidInput:      codes.InvalidArgument,
+	hcloud.ErrorCodeJSONError:         codes.InvalidArgument,
+	hcloud.ErrorCodeInvalidServerType: codes.InvalidArgument,
+	hcloud.ErrorCodeNetworksOverlap:   codes.InvalidArgument,
+	hcloud.ErrorUnsupportedError:      codes.InvalidArgument,
+
+	hcloud.ErrorCodeUnauthorized:    codes.Unauthenticated,
+	hcloud.ErrorCodeForbidden:       codes.PermissionDenied,
+	hcloud.ErrorCodeUniquenessError: codes.AlreadyExists,
+
+	// Concurrent modifications are retried with a short backoff
+	hcloud.ErrorCodeLocked:   codes.Aborted,
+	hcloud.ErrorCodeConflict: codes.Aborted,
+
+	// Requests conflicting with the state of the resource require intervention
+	hcloud.ErrorCodeProtected:             codes.FailedPrecondition,
+	hcloud.ErrorCodeResourceLocked:        codes.FailedPrecondition,
+	hcloud.ErrorCodeResourceInUse:         codes.FailedPrecondition,
+	hcloud.ErrorCodeServerNotStopped:      codes.FailedPrecondition,
+	hcloud.ErrorCodeServerAlreadyAttached: codes.FailedPrecondition,
+	hcloud.ErrorCodeVolumeAlreadyAttached: codes.FailedPrecondition,
+
+	// Temporary service errors are retried with a short backoff
+	hcloud.ErrorCodeServiceError:     codes.Unavailable,
+	hcloud.ErrorCodeUnknownError:     codes.Unavailable,
+	hcloud.ErrorCodeMaintenance:      codes.Unavailable,
+	hcloud.ErrorCodeRobotUnavailable: codes.Unavailable,
+	errorCodeTimeout:                 codes.Unavailable,
+}
+
+// getCodeForError returns the machine code matching the HCloud error code of the error given.
+//
+// PARAMETERS
+// err error Error to inspect
+func getCodeForError(err error) (codes.Code, bool) {
+	if _, ok := apis.GetRateLimitRetryAfter(err); ok {
+		return codes.ResourceExhausted, true
+	}
+
+	var errorCode hcloud.ErrorCode
+
+	var hcloudErr hcloud.Error
+	var actionErr *apis.ActionError
+
+	if errors.As(err, &hcloudErr) {
+		errorCode = hcloudErr.Code
+	} else if errors.As(err, &actionErr) {
+		errorCode = hcloud.ErrorCode(actionErr.Code)
+	} else {
+		return codes.Unknown, false
+	}
+
+	code, ok := hcloudErrorCodes[errorCode]
+	return code, ok
+}
+
+// getStatusForError returns the status error for the error given.
+// HCloud API and action errors are reported with the machine code matching their error code. Errors caused by an
+// exhausted HCloud API rate limit are reported as resource exhausted. Their message contains the retry hint. Missing
+// resources are reported with the code given as MCM creates machines reported as not found again.
+//
+// PARAMETERS
+// code codes.Code Status code to use for all other errors
+// err  error      Error to convert
+func getStatusForError(code codes.Code, err error) error {
+	if errorCode, ok := getCodeForError(err); ok {
+		code = errorCode
+	}
+
+	return status.Error(code, err.Error())
+}
+
+// getStatusForServerError returns the status error for errors of requests on the server of a machine itself. Missing
+// servers are reported as not found while all other errors are converted by getStatusForError.
+//
+// PARAMETERS
+// code codes.Code Status code to use for all other errors
+// err  error      Error to convert
+func getStatusForServerError(code codes.Code, err error) error {
+	if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	return getStatusForError(code, err)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hcloud contains the HCloud provider specific implementations to manage machines
+package hcloud
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis"
+)
+
+var _ = Describe("Errors", func() {
+	Describe("#getStatusForError", func() {
+		expectCode := func(err error, code codes.Code) {
+			statusErr := getStatusForError(codes.Internal, err)
+
+			errStatus, ok := statusErr.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(code))
+			Expect(errStatus.Message()).To(Equal(err.Error()))
+		}
+
+		DescribeTable("##HCloud API errors",
+			func(errorCode hcloud.ErrorCode, code codes.Code) {
+				expectCode(hcloud.Error{Code: errorCode, Message: "test"}, code)
+			},
+			Entry(nil, hcloud.ErrorCodeRateLimitExceeded, codes.ResourceExhausted),
+			Entry(nil, hcloud.ErrorCodeResourceLimitExceeded, codes.ResourceExhausted),
+			Entry(nil, hcloud.ErrorCodeResourceUnavailable, codes.ResourceExhausted),
+			Entry(nil, errorCodeServerTypeUnavailable, codes.ResourceExhausted),
+			Entry(nil, hcloud.ErrorCodeNoSpaceLeftInLocation, codes.ResourceExhausted),
+			Entry(nil, hcloud.ErrorCodeIPNotAvailable, codes.ResourceExhausted),
+			Entry(nil, hcloud.ErrorCodeNoSubnetAvailable, codes.ResourceExhausted),
+			Entry(nil, hcloud.ErrorCodePlacementError, codes.ResourceExhausted),
+			Entry(nil, hcloud.ErrorCodeInvalidInput, codes.InvalidArgument),
+			Entry(nil, hcloud.ErrorCodeJSONError, codes.InvalidArgument),
+			Entry(nil, hcloud.ErrorCodeInvalidServerType, codes.InvalidArgument),
+			Entry(nil, hcloud.ErrorCodeNetworksOverlap, codes.InvalidArgument),
+			Entry(nil, hcloud.ErrorUnsupportedError, codes.InvalidArgument),
+			Entry(nil, hcloud.ErrorCodeUnauthorized, codes.Unauthenticated),
+			Entry(nil, hcloud.ErrorCodeForbidden, codes.PermissionDenied),
+			Entry(nil, hcloud.ErrorCodeNotFound, codes.Internal),
+			Entry(nil, hcloud.ErrorCodeUniquenessError, codes.AlreadyExists),
+			Entry(nil, hcloud.ErrorCodeLocked, codes.Aborted),
+			Entry(nil, hcloud.ErrorCodeConflict, codes.Aborted),
+			Entry(nil, hcloud.ErrorCodeProtected, codes.FailedPrecondition),
+			Entry(nil, hcloud.ErrorCodeResourceLocked, codes.FailedPrecondition),
+			Entry(nil, hcloud.ErrorCodeResourceInUse, codes.FailedPrecondition),
+			Entry(nil, hcloud.ErrorCodeServerNotStopped, codes.FailedPrecondition),
+			Entry(nil, hcloud.ErrorCodeServerAlreadyAttached, codes.FailedPrecondition),
+			Entry(nil, hcloud.ErrorCodeVolumeAlreadyAttached, codes.FailedPrecondition),
+			Entry(nil, hcloud.ErrorCodeServiceError, codes.Unavailable),
+			Entry(nil, hcloud.ErrorCodeUnknownError, codes.Unavailable),
+			Entry(nil, hcloud.ErrorCodeMaintenance, codes.Unavailable),
+			Entry(nil, hcloud.ErrorCodeRobotUnavailable, codes.Unavailable),
+			Entry(nil, errorCodeTimeout, codes.Unavailable),
+		)
+
+		DescribeTable("##HCloud action errors",
+			func(errorCode hcloud.ErrorCode, code codes.Code) {
+				expectCode(fmt.Errorf("waiting for actions: %w", &apis.ActionError{ID: 42, Command: "create_server", Code: string(errorCode), Message: "test"}), code)
+			},
+			Entry(nil, hcloud.ErrorCodeResourceUnavailable, codes.ResourceExhausted),
+			Entry(nil, errorCodeTimeout, codes.Unavailable),
+			Entry(nil, hcloud.ErrorCode("server_error"), codes.Internal),
+		)
+
+		It("should report wrapped HCloud API errors", func() {
+			expectCode(fmt.Errorf("creating server: %w", hcloud.Error{Code: hcloud.ErrorCodeUniquenessError}), codes.AlreadyExists)
+		})
+
+		It("should report an exhausted rate limit budget as resource exhausted", func() {
+			expectCode(&apis.RateLimitError{RetryAfter: time.Second}, codes.ResourceExhausted)
+		})
+
+		It("should use the code given for unknown HCloud error codes", func() {
+			expectCode(hcloud.Error{Code: hcloud.ErrorCode("unexpected")}, codes.Internal)
+		})
+
+		It("should use the code given for other errors", func() {
+			expectCode(errors.New("test"), codes.Internal)
+		})
+	})
+
+	Describe("#getStatusForServerError", func() {
+		expectCode := func(err error, code codes.Code) {
+			statusErr := getStatusForServerError(codes.Internal, err)
+
+			errStatus, ok := statusErr.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(code))
+			Expect(errStatus.Message()).To(Equal(err.Error()))
+		}
+
+		It("should report missing servers as not found", func() {
+			expectCode(fmt.Errorf("deleting server: %w", hcloud.Error{Code: hcloud.ErrorCodeNotFound}), codes.NotFound)
+		})
+
+		It("should convert all other errors like getStatusForError", func() {
+			expectCode(hcloud.Error{Code: hcloud.ErrorCodeConflict}, codes.Aborted)
+			expectCode(errors.New("test"), codes.Internal)
+		})
+	})
+})
@@ -21,13 +21,19 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"maps"
+	"net"
 	"net/url"
-	"strconv"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
 	"github.com/hetznercloud/hcloud-go/hcloud"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 
 	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis"
@@ -74,7 +80,7 @@ func (p *MachineProvider) createMachine(ctx context.Context, req *driver.CreateM
 
 	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, getStatusForError(codes.InvalidArgument, err)
 	}
 
 	userData, ok := secret.Data["userData"]
@@ -99,394 +105,1365 @@ func (p *MachineProvider) createMachine(ctx context.Context, req *driver.CreateM
 		return nil, status.Error(errorCode, "Server already exists")
 	}
 
-	imageName := providerSpec.ImageName
-	userDataBase64Enc := base64.StdEncoding.EncodeToString(userData)
-
-	image, _, err := client.Image.GetByName(ctx, imageName)
+	candidates, err := p.getServerCandidates(ctx, client, providerSpec)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
 	}
 
-	if image == nil {
-		images, err := client.Image.AllWithOpts(ctx, hcloud.ImageListOpts{Name: imageName, IncludeDeprecated: true})
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
+	userDataBase64Enc := base64.StdEncoding.EncodeToString(userData)
 
-		if len(images) == 0 {
-			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Image %s not found", imageName))
-		} else {
-			image = images[0]
-		}
+	candidates, err = p.resolveServerCandidateImages(ctx, client, apis.GetImageSpec(providerSpec), candidates)
+	if err != nil {
+		return nil, err
 	}
 
 	region := apis.GetRegionFromZone(providerSpec.Zone)
 	startAfterCreate := false
-	zone := providerSpec.Zone
 
 	opts := hcloud.ServerCreateOpts{
-		Name:       machine.Name,
-		ServerType: &hcloud.ServerType{Name: providerSpec.ServerType},
-		Image:      image,
-		Labels: map[string]string{
+		Name: machine.Name,
+		Labels: apis.GetResourceLabels(providerSpec, getMachineMetadataLabels(providerSpec, machine, map[string]string{
 			"mcm.gardener.cloud/cluster":    providerSpec.Cluster,
 			"mcm.gardener.cloud/role":       "node",
 			"topology.kubernetes.io/region": region,
-			"topology.kubernetes.io/zone":   zone,
-		},
-		Datacenter:       &hcloud.Datacenter{Name: zone},
+		})),
 		UserData:         userDataBase64Enc,
 		StartAfterCreate: &startAfterCreate,
 	}
 
-	sshKey, _, err := client.SSHKey.GetByFingerprint(ctx, providerSpec.SSHFingerprint)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
-	} else if sshKey == nil {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("SSH key with fingerprint %s not found", providerSpec.SSHFingerprint))
-	}
-
-	opts.SSHKeys = append(opts.SSHKeys, sshKey)
-
-	if providerSpec.NetworkName != "" {
-		network, _, err := client.Network.GetByName(ctx, providerSpec.NetworkName)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		} else if network == nil {
-			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Network %s not found", providerSpec.NetworkName))
-		}
-
-		opts.Networks = append(opts.Networks, network)
-	}
-
-	serverResult, _, err := client.Server.Create(ctx, opts)
+	opts.SSHKeys, err = p.getSSHKeys(ctx, client, providerSpec)
 	if err != nil {
-		return nil, status.Error(codes.Unavailable, err.Error())
+		return nil, err
 	}
 
-	resultData.ServerID = serverResult.Server.ID
+	networkSpecs := apis.GetNetworkSpecs(providerSpec)
 
-	server, err = apis.WaitForActionsAndGetServer(ctx, client, serverResult.Server)
+	networks, err := p.getNetworks(ctx, client, networkSpecs)
 	if err != nil {
-		return nil, status.Error(codes.Unknown, err.Error())
+		return nil, err
 	}
 
-	if providerSpec.FloatingPoolName != "" {
-		placementGroupID, err := strconv.Atoi(providerSpec.PlacementGroupID)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+	for index, network := range networks {
+		if apis.IsNetworkAttachedAtCreation(&networkSpecs[index]) {
+			opts.Networks = append(opts.Networks, network)
 		}
+	}
 
-		placementGroup, _, err := client.PlacementGroup.GetByID(ctx, placementGroupID)
+	for _, firewallSpec := range providerSpec.Firewalls {
+		firewalls, err := p.getFirewalls(ctx, client, &firewallSpec)
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, err
 		}
 
-		_, _, err = client.Server.AddToPlacementGroup(ctx, server, placementGroup)
-		if err != nil {
-			return nil, status.Error(codes.Unavailable, err.Error())
+		for _, firewall := range firewalls {
+			opts.Firewalls = append(opts.Firewalls, &hcloud.ServerCreateFirewall{Firewall: *firewall})
 		}
+	}
 
-		server, err = apis.WaitForActionsAndGetServer(ctx, client, serverResult.Server)
+	placementGroupSpec := apis.GetPlacementGroupSpec(providerSpec)
+	if placementGroupSpec != nil {
+		opts.PlacementGroup, err = p.getPlacementGroup(ctx, client, providerSpec, placementGroupSpec)
 		if err != nil {
-			return nil, status.Error(codes.Unknown, err.Error())
+			return nil, err
 		}
 	}
 
-	if providerSpec.FloatingPoolName != "" {
-		name := fmt.Sprintf("%s-%s-ipv4", providerSpec.FloatingPoolName, machine.Name)
-
-		floatingIP, _, err := client.FloatingIP.GetByName(ctx, name)
-		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
-		} else if floatingIP == nil {
-			opts := hcloud.FloatingIPCreateOpts{
-				Name:   &name,
-				Type:   hcloud.FloatingIPTypeIPv4,
-				Server: server,
-				Labels: map[string]string{
-					"mcm.gardener.cloud/cluster":                         providerSpec.Cluster,
-					"networking.hcloud.mcm.gardener.cloud/floating-pool": providerSpec.FloatingPoolName,
-				},
-			}
-
-			ipResult, _, err := client.FloatingIP.Create(ctx, opts)
-			if err != nil {
-				return nil, status.Error(codes.Internal, err.Error())
-			}
-
-			resultData.FloatingIPID = ipResult.FloatingIP.ID
+	serverResult, err := p.createServer(ctx, client, providerSpec, candidates, opts)
+	if err != nil && placementGroupSpec != nil && placementGroupSpec.AutoCreate {
+		// The auto-created placement group may have been deleted together with its last server concurrently
+		placementGroup, _, lookupErr := client.PlacementGroup.GetByID(ctx, opts.PlacementGroup.ID)
+		if lookupErr == nil && placementGroup == nil {
+			klog.V(2).Infof("Placement group %s has been deleted concurrently and is created again for %q", opts.PlacementGroup.Name, opts.Name)
 
-			_, err = apis.WaitForActionsAndGetFloatingIP(ctx, client, ipResult.FloatingIP)
+			opts.PlacementGroup, err = p.getPlacementGroup(ctx, client, providerSpec, placementGroupSpec)
 			if err != nil {
-				return nil, status.Error(codes.Internal, err.Error())
+				return nil, err
 			}
-		}
-	}
 
-	if hcloud.ServerStatusStarting != server.Status && hcloud.ServerStatusRunning != server.Status {
-		_, _, err = client.Server.Poweron(ctx, server)
-		if err != nil {
-			return nil, status.Error(codes.Aborted, err.Error())
+			serverResult, err = p.createServer(ctx, client, providerSpec, candidates, opts)
 		}
 	}
-
-	server, err = apis.WaitForActionsAndGetServer(ctx, client, serverResult.Server)
 	if err != nil {
-		return nil, status.Error(codes.Unknown, err.Error())
-	}
-
-	// For some reason our machine was not started, return an error in this case
-	// This behavior was observed from time to time. Therefore, this check is meaningful
-	if server.Status != hcloud.ServerStatusRunning {
-		return nil, status.Error(codes.Unknown, "Server was not started for some reason.")
+		return nil, err
 	}
 
-	// Compare running results with expectation
-	unexpectedState := ""
+	resultData.ServerID = serverResult.Server.ID
 
-	if providerSpec.FloatingPoolName != "" && len(server.PublicNet.FloatingIPs) != 1 {
-		unexpectedState = "Floating IP set-up failed"
+	server, err = apis.WaitForActionsAndGetServer(ctx, client, serverResult.Server, append([]*hcloud.Action{serverResult.Action}, serverResult.NextActions...)...)
+	if err != nil {
+		return nil, getStatusForError(codes.Unknown, err)
 	}
 
-	if providerSpec.NetworkName != "" && len(server.PrivateNet) != 1 {
-		unexpectedState = "Private network set-up failed"
+	err = p.labelServerPrimaryIPs(ctx, client, providerSpec, machine.Name, server)
+	if err != nil {
+		return nil, err
 	}
 
-	if unexpectedState != "" {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("Server state does not match expectation: %s", unexpectedState))
+	for index, volumeSpec := range providerSpec.Volumes {
+		volume, err := p.createMachineVolume(ctx, client, providerSpec, &volumeSpec, index, machine.Name, server)
+		if volume != nil {
+			resultData.VolumeIDs = append(resultData.VolumeIDs, volume.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	response := &driver.CreateMachineResponse{
-		ProviderID: transcoder.EncodeProviderID(providerSpec.Zone, server.ID),
+		ProviderID: transcoder.EncodeProviderID(getServerZone(providerSpec, server), server.ID),
 		NodeName:   server.Name,
 	}
 
 	return response, nil
 }
 
-// createMachineOnErrorCleanup cleans up a failed machine creation request
+// createServer creates the server trying all server type and zone candidates in order of preference until one of
+// them is available
 //
 // PARAMETERS
-// ctx context.Context              Execution context
-// req *driver.CreateMachineRequest The create request for VM creation
-// err error                        Error encountered
-func (p *MachineProvider) createMachineValidateDeploymentAndCleanup(ctx context.Context, req *driver.CreateMachineRequest, server *hcloud.Server) bool {
-	var (
-		machine      = req.Machine
-		machineClass = req.MachineClass
-		secret       = req.Secret
-	)
+// ctx          context.Context         Execution context
+// client       *hcloud.Client          HCloud client
+// providerSpec *apis.ProviderSpec      Provider specification
+// candidates   []serverCandidate       Server types and zones to try
+// opts         hcloud.ServerCreateOpts Server creation options without server type, datacenter and image
+func (p *MachineProvider) createServer(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, candidates []serverCandidate, opts hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, error) {
+	var lastErr error
+
+	labels := opts.Labels
+
+	for _, candidate := range candidates {
+		var err error
+
+		opts.ServerType = &hcloud.ServerType{Name: candidate.serverType}
+		opts.Datacenter = &hcloud.Datacenter{Name: candidate.zone}
+		opts.Image = candidate.image
+
+		opts.Labels = map[string]string{
+			"node.kubernetes.io/instance-type": candidate.serverType,
+			"topology.kubernetes.io/zone":      candidate.zone,
+		}
+		for key, value := range labels {
+			opts.Labels[key] = value
+		}
 
-	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
-	if err != nil {
-		return false
-	}
+		if providerSpec.PublicNet != nil {
+			opts.PublicNet, err = p.getServerCreatePublicNet(ctx, client, providerSpec, candidate.zone)
+			if err != nil {
+				return hcloud.ServerCreateResult{}, err
+			}
+		}
 
-	isCleanupAvailable := true
+		serverResult, _, err := client.Server.Create(ctx, opts)
+		if err == nil {
+			return serverResult, nil
+		}
 
-	if clusterLabel, ok := server.Labels["mcm.gardener.cloud/cluster"]; !ok || providerSpec.Cluster != clusterLabel {
-		isCleanupAvailable = false
-	}
+		if !isCapacityError(err) {
+			return hcloud.ServerCreateResult{}, getStatusForError(codes.Unavailable, err)
+		}
 
-	if roleLabel, ok := server.Labels["mcm.gardener.cloud/role"]; !ok || roleLabel != "node" {
-		isCleanupAvailable = false
+		klog.V(2).Infof("Server type %s is unavailable in %s for %q: %s", candidate.serverType, candidate.zone, opts.Name, err)
+		lastErr = err
 	}
 
-	region := apis.GetRegionFromZone(providerSpec.Zone)
+	return hcloud.ServerCreateResult{}, getStatusForError(codes.ResourceExhausted, lastErr)
+}
 
-	if regionLabel, ok := server.Labels["topology.kubernetes.io/region"]; !ok || region != regionLabel {
-		isCleanupAvailable = false
-	}
+// isCapacityError returns true if the given error reports a server type to be unavailable in the datacenter requested
+//
+// PARAMETERS
+// err error Error to inspect
+func isCapacityError(err error) bool {
+	return hcloud.IsError(err, hcloud.ErrorCodeResourceUnavailable) || hcloud.IsError(err, errorCodeServerTypeUnavailable)
+}
 
-	if zoneLabel, ok := server.Labels["topology.kubernetes.io/zone"]; !ok || providerSpec.Zone != zoneLabel {
-		isCleanupAvailable = false
+// getMachineMetadataLabels returns the labels given merged with labels identifying the namespace and owners of the
+// machine as well as the node template labels requested by the provider spec. Labels given take precedence.
+//
+// PARAMETERS
+// providerSpec *apis.ProviderSpec Provider specification
+// machine      *v1alpha1.Machine  Machine to return labels for
+// labels       map[string]string  Labels set by the provider
+func getMachineMetadataLabels(providerSpec *apis.ProviderSpec, machine *v1alpha1.Machine, labels map[string]string) map[string]string {
+	metadataLabels := make(map[string]string)
+
+	for _, key := range providerSpec.NodeTemplateLabels {
+		if value, ok := machine.Spec.NodeTemplateSpec.Labels[key]; ok {
+			metadataLabels[key] = value
+		}
 	}
 
-	if isCleanupAvailable {
-		client := apis.GetClientForToken(string(req.Secret.Data["token"]))
-		resultData := ctx.Value(CtxWrapDataKey("MethodData")).(*CreateMachineMethodData)
+	metadataLabels["mcm.gardener.cloud/namespace"] = machine.Namespace
 
-		resultData.ServerID = server.ID
+	for _, ownerReference := range machine.OwnerReferences {
+		if ownerReference.Kind != "MachineSet" {
+			continue
+		}
 
-		if providerSpec.FloatingPoolName != "" {
-			name := fmt.Sprintf("%s-%s-ipv4", providerSpec.FloatingPoolName, machine.Name)
+		metadataLabels["mcm.gardener.cloud/machine-set"] = ownerReference.Name
 
-			floatingIP, _, _ := client.FloatingIP.GetByName(ctx, name)
-			if floatingIP != nil {
-				resultData.FloatingIPID = floatingIP.ID
+		// MachineSets of a MachineDeployment are named "<deployment>-<hash>" and their machines carry the hash label
+		if _, ok := machine.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey]; ok {
+			if index := strings.LastIndex(ownerReference.Name, "-"); index > 0 {
+				metadataLabels["mcm.gardener.cloud/machine-deployment"] = ownerReference.Name[:index]
 			}
 		}
 	}
 
-	return isCleanupAvailable
+	for key, value := range metadataLabels {
+		if len(k8svalidation.IsValidLabelValue(value)) > 0 {
+			klog.V(3).Infof("Label %s of machine %q is skipped as %q is not a valid label value", key, machine.Name, value)
+			delete(metadataLabels, key)
+		}
+	}
+
+	for key, value := range labels {
+		metadataLabels[key] = value
+	}
+
+	return metadataLabels
 }
 
-// createMachineOnErrorCleanup cleans up a failed machine creation request
+// getServerZone returns the zone the server has been created in
 //
 // PARAMETERS
-// ctx context.Context              Execution context
-// req *driver.CreateMachineRequest The create request for VM creation
-// err error                        Error encountered
-func (p *MachineProvider) createMachineOnErrorCleanup(ctx context.Context, req *driver.CreateMachineRequest) {
-	client := apis.GetClientForToken(string(req.Secret.Data["token"]))
-	resultData := ctx.Value(CtxWrapDataKey("MethodData")).(*CreateMachineMethodData)
-
-	var server *hcloud.Server
-	if resultData.ServerID != 0 {
-		server, _, _ = client.Server.GetByID(ctx, resultData.ServerID)
-	} else {
-		server, _, _ = client.Server.GetByName(ctx, req.Machine.Name)
-	}
-	if nil != server {
-		_, _ = client.Server.Delete(ctx, server)
+// providerSpec *apis.ProviderSpec Provider specification
+// server       *hcloud.Server     Server to get the zone of
+func getServerZone(providerSpec *apis.ProviderSpec, server *hcloud.Server) string {
+	if zone, ok := server.Labels["topology.kubernetes.io/zone"]; ok && zone != "" {
+		return zone
 	}
 
-	if resultData.FloatingIPID != 0 {
-		floatingIP, _, _ := client.FloatingIP.GetByID(ctx, resultData.FloatingIPID)
-		if nil != floatingIP {
-			_, _ = client.FloatingIP.Delete(ctx, floatingIP)
-		}
-	}
+	return providerSpec.Zone
 }
 
-// DeleteMachine handles a machine deletion request
+// resolveServerCandidateImages resolves the image matching the architecture of the server type of each candidate and
+// returns the candidates resolved. Candidates with server types of another architecture than the one requested
+// explicitly by the image specification are skipped.
 //
 // PARAMETERS
-// ctx context.Context              Execution context
-// req *driver.CreateMachineRequest The delete request for VM deletion
-func (p *MachineProvider) DeleteMachine(ctx context.Context, req *driver.DeleteMachineRequest) (*driver.DeleteMachineResponse, error) {
+// ctx        context.Context   Execution context
+// client     *hcloud.Client    HCloud client
+// imageSpec  *apis.ImageSpec   Image specification
+// candidates []serverCandidate Server candidates to resolve the image for
+func (p *MachineProvider) resolveServerCandidateImages(ctx context.Context, client *hcloud.Client, imageSpec *apis.ImageSpec, candidates []serverCandidate) ([]serverCandidate, error) {
+	architectures := make(map[string]hcloud.Architecture)
+	images := make(map[hcloud.Architecture]*hcloud.Image)
+
 	var (
-		machine      = req.Machine
-		machineClass = req.MachineClass
-		secret       = req.Secret
+		mismatchErr error
+		resolved    []serverCandidate
 	)
 
-	// Log messages to track delete request
-	klog.V(2).Infof("Machine deletion request has been received for %q", machine.Name)
-	defer klog.V(2).Infof("Machine deletion request has been processed for %q", machine.Name)
+	for _, candidate := range candidates {
+		architecture, ok := architectures[candidate.serverType]
+		if !ok {
+			serverType, _, err := client.ServerType.GetByName(ctx, candidate.serverType)
+			if err != nil {
+				return nil, getStatusForError(codes.InvalidArgument, err)
+			} else if serverType == nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Server type %s not found", candidate.serverType))
+			}
 
-	client := apis.GetClientForToken(string(secret.Data["token"]))
+			architecture = serverType.Architecture
+			architectures[candidate.serverType] = architecture
+		}
 
-	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
-	}
+		if imageSpec.Architecture != "" && architecture != "" && hcloud.Architecture(imageSpec.Architecture) != architecture {
+			klog.V(2).Infof("Skipping server type %s in %s as its architecture %s does not match image architecture %s", candidate.serverType, candidate.zone, architecture, imageSpec.Architecture)
 
-	var server *hcloud.Server
-	if machine.Spec.ProviderID != "" {
-		serverID, err := transcoder.DecodeServerIDFromProviderID(machine.Spec.ProviderID)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			if mismatchErr == nil {
+				mismatchErr = status.Error(codes.InvalidArgument, fmt.Sprintf("Image architecture %s does not match architecture %s of server type %s", imageSpec.Architecture, architecture, candidate.serverType))
+			}
+
+			continue
 		}
 
-		server, _, err = client.Server.GetByID(ctx, serverID)
+		image, ok := images[architecture]
+		if !ok {
+			var err error
+
+			image, err = p.getImage(ctx, client, imageSpec, architecture)
+			if err != nil {
+				return nil, err
+			}
+
+			images[architecture] = image
+		}
+
+		candidate.image = image
+		resolved = append(resolved, candidate)
+	}
+
+	if len(resolved) == 0 && mismatchErr != nil {
+		return nil, mismatchErr
+	}
+
+	return resolved, nil
+}
+
+// getImage returns the image selected by the given image specification for the architecture given. Images matching a
+// name or label selector are ordered by deprecation and creation date to select the newest image deterministically.
+//
+// PARAMETERS
+// ctx          context.Context     Execution context
+// client       *hcloud.Client      HCloud client
+// imageSpec    *apis.ImageSpec     Image specification
+// architecture hcloud.Architecture CPU architecture of the server type or an empty string for any
+func (p *MachineProvider) getImage(ctx context.Context, client *hcloud.Client, imageSpec *apis.ImageSpec, architecture hcloud.Architecture) (*hcloud.Image, error) {
+	if architecture == "" {
+		architecture = hcloud.Architecture(imageSpec.Architecture)
+	}
+
+	if imageSpec.ID != 0 {
+		image, _, err := client.Image.GetByID(ctx, imageSpec.ID)
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, getStatusForError(codes.InvalidArgument, err)
+		} else if image == nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Image %d not found", imageSpec.ID))
+		} else if architecture != "" && image.Architecture != architecture {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Image %d has architecture %s but %s is required", image.ID, image.Architecture, architecture))
 		}
+
+		return image, nil
+	}
+
+	opts := hcloud.ImageListOpts{
+		ListOpts: hcloud.ListOpts{PerPage: 50},
+		Status:   []hcloud.ImageStatus{hcloud.ImageStatusAvailable},
+	}
+
+	reference := imageSpec.Name
+
+	if imageSpec.LabelSelector != "" {
+		opts.LabelSelector = imageSpec.LabelSelector
+		opts.Type = []hcloud.ImageType{hcloud.ImageTypeSnapshot}
+
+		reference = fmt.Sprintf("label selector %s", imageSpec.LabelSelector)
 	} else {
-		server, _, err = client.Server.GetByName(ctx, machine.Name)
+		opts.Name = imageSpec.Name
+		opts.IncludeDeprecated = true
 	}
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
-	} else if server == nil {
-		klog.V(3).Infof("VM %s does not exist", machine.Name)
-		return &driver.DeleteMachineResponse{}, nil
+
+	if architecture != "" {
+		opts.Architecture = []hcloud.Architecture{architecture}
+		reference = fmt.Sprintf("%s (%s)", reference, architecture)
 	}
 
-	_, err = client.Server.Delete(ctx, server)
+	images, err := client.Image.AllWithOpts(ctx, opts)
 	if err != nil {
-		return nil, status.Error(codes.Unavailable, err.Error())
+		return nil, getStatusForError(codes.InvalidArgument, err)
+	} else if len(images) == 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Image %s not found", reference))
 	}
 
-	if providerSpec.FloatingPoolName != "" {
-		name := fmt.Sprintf("%s-%s-ipv4", providerSpec.FloatingPoolName, machine.Name)
+	slices.SortStableFunc(images, func(a, b *hcloud.Image) int {
+		if a.IsDeprecated() != b.IsDeprecated() {
+			if a.IsDeprecated() {
+				return 1
+			}
 
-		floatingIP, _, err := client.FloatingIP.GetByName(ctx, name)
+			return -1
+		}
+
+		if cmp := b.Created.Compare(a.Created); cmp != 0 {
+			return cmp
+		}
+
+		return b.ID - a.ID
+	})
+
+	return images[0], nil
+}
+
+// getNetworks returns the networks referenced by the given network specifications
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// networkSpecs []apis.NetworkSpec Network specifications
+func (p *MachineProvider) getNetworks(ctx context.Context, client *hcloud.Client, networkSpecs []apis.NetworkSpec) ([]*hcloud.Network, error) {
+	var networks []*hcloud.Network
+
+	for _, networkSpec := range networkSpecs {
+		network, _, err := client.Network.GetByName(ctx, networkSpec.Name)
 		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
-		} else if nil != floatingIP {
-			_, err = client.FloatingIP.Delete(ctx, floatingIP)
-			if err != nil {
-				return nil, status.Error(codes.Unavailable, err.Error())
-			}
+			return nil, getStatusForError(codes.InvalidArgument, err)
+		} else if network == nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Network %s not found", networkSpec.Name))
 		}
+
+		networks = append(networks, network)
 	}
 
-	return &driver.DeleteMachineResponse{}, nil
+	return networks, nil
 }
 
-// GetMachineStatus handles a machine get status request
+// getFirewalls returns the firewalls referenced by the given firewall specification
 //
 // PARAMETERS
-// ctx context.Context              Execution context
-// req *driver.CreateMachineRequest The get request for VM info
-func (p *MachineProvider) GetMachineStatus(ctx context.Context, req *driver.GetMachineStatusRequest) (*driver.GetMachineStatusResponse, error) {
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// firewallSpec *apis.FirewallSpec Firewall specification
+func (p *MachineProvider) getFirewalls(ctx context.Context, client *hcloud.Client, firewallSpec *apis.FirewallSpec) ([]*hcloud.Firewall, error) {
+	var firewalls []*hcloud.Firewall
+
+	if firewallSpec.LabelSelector != "" {
+		listOpts := hcloud.FirewallListOpts{
+			ListOpts: hcloud.ListOpts{
+				LabelSelector: firewallSpec.LabelSelector,
+				PerPage:       50,
+			},
+		}
+
+		firewalls, err := client.Firewall.AllWithOpts(ctx, listOpts)
+		if err != nil {
+			return nil, getStatusForError(codes.Unavailable, err)
+		} else if len(firewalls) == 0 {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("No firewall found for label selector %s", firewallSpec.LabelSelector))
+		}
+
+		return firewalls, nil
+	}
+
 	var (
-		err          error
-		machine      = req.Machine
-		secret       = req.Secret
-		machineClass = req.MachineClass
-		server       *hcloud.Server
-		serverID     int
+		err      error
+		firewall *hcloud.Firewall
+		notFound string
 	)
 
-	// Log messages to track start and end of request
-	klog.V(2).Infof("Get request has been received for %q", machine.Name)
-	defer klog.V(2).Infof("Machine get request has been processed successfully for %q", machine.Name)
+	if firewallSpec.ID != 0 {
+		firewall, _, err = client.Firewall.GetByID(ctx, firewallSpec.ID)
+		notFound = fmt.Sprintf("Firewall with ID %d not found", firewallSpec.ID)
+	} else {
+		firewall, _, err = client.Firewall.GetByName(ctx, firewallSpec.Name)
+		notFound = fmt.Sprintf("Firewall %s not found", firewallSpec.Name)
+	}
 
-	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, getStatusForError(codes.InvalidArgument, err)
+	} else if firewall == nil {
+		return nil, status.Error(codes.InvalidArgument, notFound)
 	}
 
-	client := apis.GetClientForToken(string(secret.Data["token"]))
+	firewalls = append(firewalls, firewall)
 
-	// Handle case where machine lookup occurs with empty provider ID
-	if machine.Spec.ProviderID == "" {
-		server, _, err = client.Server.GetByName(ctx, machine.Name)
+	return firewalls, nil
+}
 
-		if server == nil {
-			return nil, status.Error(codes.NotFound, fmt.Sprintf("Provider ID for machine %q is not defined (%q)", machine.Name, err))
-		}
+// getSSHKeys returns the SSH keys referenced by the SSH key specifications of the provider spec. SSH keys referenced
+// more than once are only returned once.
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+func (p *MachineProvider) getSSHKeys(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec) ([]*hcloud.SSHKey, error) {
+	var sshKeys []*hcloud.SSHKey
 
-		serverID = server.ID
-	} else {
-		serverID, err := transcoder.DecodeServerIDFromProviderID(machine.Spec.ProviderID)
+	sshKeyIDs := make(map[int]bool)
+
+	for _, sshKeySpec := range apis.GetSSHKeySpecs(providerSpec) {
+		specSSHKeys, err := p.getSSHKeysForSpec(ctx, client, &sshKeySpec, providerSpec.SSHKeysOptional)
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, err
 		}
 
-		server, _, err = client.Server.GetByID(ctx, serverID)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+		for _, sshKey := range specSSHKeys {
+			if !sshKeyIDs[sshKey.ID] {
+				sshKeyIDs[sshKey.ID] = true
+				sshKeys = append(sshKeys, sshKey)
+			}
 		}
 	}
 
-	if err != nil {
-		return nil, status.Error(codes.Unavailable, err.Error())
-	} else if server == nil {
-		return nil, status.Error(codes.NotFound, fmt.Sprintf("VM %s (%d) does not exist", machine.Name, serverID))
+	if len(sshKeys) == 0 && !providerSpec.SSHKeysOptional {
+		return nil, status.Error(codes.InvalidArgument, "No SSH key configured")
 	}
 
-	providerID := transcoder.EncodeProviderID(providerSpec.Zone, serverID)
-	return &driver.GetMachineStatusResponse{ProviderID: providerID, NodeName: server.Name}, nil
+	return sshKeys, nil
 }
 
-// ListMachines lists all the machines possibilly created by a providerSpec
+// getSSHKeysForSpec returns the SSH keys referenced by the given SSH key specification
 //
 // PARAMETERS
-// ctx context.Context              Execution context
-// req *driver.CreateMachineRequest The request object to get a list of VMs belonging to a machineClass
-func (p *MachineProvider) ListMachines(ctx context.Context, req *driver.ListMachinesRequest) (*driver.ListMachinesResponse, error) {
-	var (
-		machineClass = req.MachineClass
-		secret       = req.Secret
-	)
+// ctx        context.Context  Execution context
+// client     *hcloud.Client   HCloud client
+// sshKeySpec *apis.SSHKeySpec SSH key specification
+// isOptional bool             True if label selectors may not match any SSH key
+func (p *MachineProvider) getSSHKeysForSpec(ctx context.Context, client *hcloud.Client, sshKeySpec *apis.SSHKeySpec, isOptional bool) ([]*hcloud.SSHKey, error) {
+	if sshKeySpec.LabelSelector != "" {
+		listOpts := hcloud.SSHKeyListOpts{
+			ListOpts: hcloud.ListOpts{
+				LabelSelector: sshKeySpec.LabelSelector,
+				PerPage:       50,
+			},
+		}
 
-	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		sshKeys, err := client.SSHKey.AllWithOpts(ctx, listOpts)
+		if err != nil {
+			return nil, getStatusForError(codes.Unavailable, err)
+		} else if len(sshKeys) == 0 && !isOptional {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("No SSH key found for label selector %s", sshKeySpec.LabelSelector))
+		}
+
+		return sshKeys, nil
+	}
+
+	var (
+		err      error
+		sshKey   *hcloud.SSHKey
+		notFound string
+	)
+
+	if sshKeySpec.Fingerprint != "" {
+		sshKey, _, err = client.SSHKey.GetByFingerprint(ctx, sshKeySpec.Fingerprint)
+		notFound = fmt.Sprintf("SSH key with fingerprint %s not found", sshKeySpec.Fingerprint)
+	} else {
+		sshKey, _, err = client.SSHKey.GetByName(ctx, sshKeySpec.Name)
+		notFound = fmt.Sprintf("SSH key %s not found", sshKeySpec.Name)
+	}
+
+	if err != nil {
+		return nil, getStatusForError(codes.InvalidArgument, err)
+	} else if sshKey == nil {
+		return nil, status.Error(codes.InvalidArgument, notFound)
+	}
+
+	return []*hcloud.SSHKey{sshKey}, nil
+}
+
+// getPlacementGroup returns the placement group referenced by the given placement group specification.
+// The placement group is created if it does not exist and automatic creation is requested.
+//
+// PARAMETERS
+// ctx                context.Context          Execution context
+// client             *hcloud.Client           HCloud client
+// providerSpec       *apis.ProviderSpec       Provider specification
+// placementGroupSpec *apis.PlacementGroupSpec Placement group specification
+func (p *MachineProvider) getPlacementGroup(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, placementGroupSpec *apis.PlacementGroupSpec) (*hcloud.PlacementGroup, error) {
+	if placementGroupSpec.ID != 0 {
+		placementGroup, _, err := client.PlacementGroup.GetByID(ctx, placementGroupSpec.ID)
+		if err != nil {
+			return nil, getStatusForError(codes.InvalidArgument, err)
+		} else if placementGroup == nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Placement group with ID %d not found", placementGroupSpec.ID))
+		}
+
+		return placementGroup, nil
+	}
+
+	placementGroup, _, err := client.PlacementGroup.GetByName(ctx, placementGroupSpec.Name)
+	if err != nil {
+		return nil, getStatusForError(codes.InvalidArgument, err)
+	} else if placementGroup != nil {
+		return placementGroup, nil
+	} else if !placementGroupSpec.AutoCreate {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Placement group %s not found", placementGroupSpec.Name))
+	}
+
+	placementGroupType := hcloud.PlacementGroupTypeSpread
+	if placementGroupSpec.Type != "" {
+		placementGroupType = hcloud.PlacementGroupType(placementGroupSpec.Type)
+	}
+
+	opts := hcloud.PlacementGroupCreateOpts{
+		Name: placementGroupSpec.Name,
+		Type: placementGroupType,
+		Labels: map[string]string{
+			"mcm.gardener.cloud/cluster":                       providerSpec.Cluster,
+			"placement.hcloud.mcm.gardener.cloud/auto-created": "true",
+		},
+	}
+
+	placementGroupResult, _, err := client.PlacementGroup.Create(ctx, opts)
+	if err != nil {
+		// The placement group may have been created by a concurrent request
+		placementGroup, _, lookupErr := client.PlacementGroup.GetByName(ctx, placementGroupSpec.Name)
+		if lookupErr != nil || placementGroup == nil {
+			return nil, getStatusForError(codes.Unavailable, err)
+		}
+
+		return placementGroup, nil
+	}
+
+	return placementGroupResult.PlacementGroup, nil
+}
+
+// getServerCreatePublicNet returns the public network configuration for the server to be created
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// zone         string             Datacenter zone
+func (p *MachineProvider) getServerCreatePublicNet(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, zone string) (*hcloud.ServerCreatePublicNet, error) {
+	var err error
+
+	publicNet := &hcloud.ServerCreatePublicNet{
+		EnableIPv4: apis.IsPublicIPv4Enabled(providerSpec),
+		EnableIPv6: apis.IsPublicIPv6Enabled(providerSpec),
+	}
+
+	if providerSpec.PublicNet.IPv4Pool != nil {
+		publicNet.IPv4, err = p.getPrimaryIPFromPool(ctx, client, providerSpec.PublicNet.IPv4Pool, hcloud.PrimaryIPTypeIPv4, zone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if providerSpec.PublicNet.IPv6Pool != nil {
+		publicNet.IPv6, err = p.getPrimaryIPFromPool(ctx, client, providerSpec.PublicNet.IPv6Pool, hcloud.PrimaryIPTypeIPv6, zone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return publicNet, nil
+}
+
+// labelServerPrimaryIPs adds the labels of the provider spec to the primary IPs of the server given. Labels already
+// set are kept to not change the membership of primary IPs in pools. Primary IPs created together with the server are
+// labelled with the cluster and machine as well for the orphan collector to find them.
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// machineName  string             Machine name
+// server       *hcloud.Server     Server the primary IPs are assigned to
+func (p *MachineProvider) labelServerPrimaryIPs(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, machineName string, server *hcloud.Server) error {
+	var ipv4Pool, ipv6Pool *apis.PrimaryIPPoolSpec
+	if providerSpec.PublicNet != nil {
+		ipv4Pool = providerSpec.PublicNet.IPv4Pool
+		ipv6Pool = providerSpec.PublicNet.IPv6Pool
+	}
+
+	pools := map[int]*apis.PrimaryIPPoolSpec{
+		server.PublicNet.IPv4.ID: ipv4Pool,
+		server.PublicNet.IPv6.ID: ipv6Pool,
+	}
+
+	for _, primaryIPID := range []int{server.PublicNet.IPv4.ID, server.PublicNet.IPv6.ID} {
+		if primaryIPID == 0 {
+			continue
+		}
+
+		primaryIP, _, err := client.PrimaryIP.GetByID(ctx, primaryIPID)
+		if err != nil {
+			return getStatusForError(codes.Unavailable, err)
+		} else if primaryIP == nil {
+			continue
+		}
+
+		labels := maps.Clone(primaryIP.Labels)
+		if pools[primaryIPID] == nil {
+			if labels == nil {
+				labels = map[string]string{}
+			}
+
+			labels["mcm.gardener.cloud/cluster"] = providerSpec.Cluster
+			labels["networking.hcloud.mcm.gardener.cloud/machine"] = machineName
+		}
+
+		labels = apis.GetResourceLabels(providerSpec, labels)
+		if maps.Equal(labels, primaryIP.Labels) {
+			continue
+		}
+
+		_, _, err = client.PrimaryIP.Update(ctx, primaryIP, hcloud.PrimaryIPUpdateOpts{Labels: &labels})
+		if err != nil {
+			return getStatusForError(codes.Unavailable, err)
+		}
+	}
+
+	return nil
+}
+
+// getPrimaryIPFromPool returns an unassigned primary IP of the pool given
+//
+// PARAMETERS
+// ctx    context.Context         Execution context
+// client *hcloud.Client          HCloud client
+// pool   *apis.PrimaryIPPoolSpec Primary IP pool specification
+// ipType hcloud.PrimaryIPType    Primary IP type
+// zone   string                  Datacenter zone
+func (p *MachineProvider) getPrimaryIPFromPool(ctx context.Context, client *hcloud.Client, pool *apis.PrimaryIPPoolSpec, ipType hcloud.PrimaryIPType, zone string) (*hcloud.PrimaryIP, error) {
+	listOpts := hcloud.PrimaryIPListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: pool.LabelSelector,
+			PerPage:       50,
+		},
+	}
+
+	primaryIPs, err := client.PrimaryIP.AllWithOpts(ctx, listOpts)
+	if err != nil {
+		return nil, getStatusForError(codes.Unavailable, err)
+	}
+
+	for _, primaryIP := range primaryIPs {
+		if primaryIP.Type != ipType || primaryIP.AssigneeID != 0 || primaryIP.Datacenter == nil || primaryIP.Datacenter.Name != zone {
+			continue
+		}
+
+		// Primary IPs of a pool must survive failed machine creations
+		if primaryIP.AutoDelete {
+			autoDelete := false
+
+			primaryIP, _, err = client.PrimaryIP.Update(ctx, primaryIP, hcloud.PrimaryIPUpdateOpts{AutoDelete: &autoDelete})
+			if err != nil {
+				return nil, getStatusForError(codes.Unavailable, err)
+			}
+		}
+
+		return primaryIP, nil
+	}
+
+	return nil, status.Error(codes.ResourceExhausted, fmt.Sprintf("No unassigned primary %s found for label selector %s in %s", ipType, pool.LabelSelector, zone))
+}
+
+// createMachineVolume creates and attaches the volume defined by the given spec to the server
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// volumeSpec   *apis.VolumeSpec   Volume specification
+// index        int                Index of the volume specification
+// machineName  string             Machine name
+// server       *hcloud.Server     Server to attach the volume to
+func (p *MachineProvider) createMachineVolume(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, volumeSpec *apis.VolumeSpec, index int, machineName string, server *hcloud.Server) (*hcloud.Volume, error) {
+	opts := hcloud.VolumeCreateOpts{
+		Name:   apis.GetVolumeName(volumeSpec, machineName, index),
+		Size:   volumeSpec.Size,
+		Server: server,
+		Labels: apis.GetResourceLabels(providerSpec, map[string]string{
+			"mcm.gardener.cloud/cluster":                providerSpec.Cluster,
+			"storage.hcloud.mcm.gardener.cloud/machine": machineName,
+		}),
+		Automount: &volumeSpec.Automount,
+	}
+
+	// Retained volumes are kept on purpose and must not be collected as orphans
+	if volumeSpec.DeletionPolicy == apis.DeletionPolicyRetain {
+		opts.Labels["storage.hcloud.mcm.gardener.cloud/retain"] = "true"
+	}
+
+	if volumeSpec.Format != "" {
+		opts.Format = &volumeSpec.Format
+	}
+
+	volumeResult, _, err := client.Volume.Create(ctx, opts)
+	if err != nil {
+		return nil, getStatusForError(codes.Unavailable, err)
+	}
+
+	volume, err := apis.WaitForActionsAndGetVolume(ctx, client, volumeResult.Volume, append([]*hcloud.Action{volumeResult.Action}, volumeResult.NextActions...)...)
+	if err != nil {
+		return volumeResult.Volume, getStatusForError(codes.Internal, err)
+	}
+
+	return volume, nil
+}
+
+// createMachineOnErrorCleanup cleans up a failed machine creation request
+//
+// PARAMETERS
+// ctx context.Context              Execution context
+// req *driver.CreateMachineRequest The create request for VM creation
+// err error                        Error encountered
+func (p *MachineProvider) createMachineValidateDeploymentAndCleanup(ctx context.Context, req *driver.CreateMachineRequest, server *hcloud.Server) bool {
+	var (
+		machine      = req.Machine
+		machineClass = req.MachineClass
+		secret       = req.Secret
+	)
+
+	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
+	if err != nil {
+		return false
+	}
+
+	isCleanupAvailable := true
+
+	if clusterLabel, ok := server.Labels["mcm.gardener.cloud/cluster"]; !ok || providerSpec.Cluster != clusterLabel {
+		isCleanupAvailable = false
+	}
+
+	if roleLabel, ok := server.Labels["mcm.gardener.cloud/role"]; !ok || roleLabel != "node" {
+		isCleanupAvailable = false
+	}
+
+	region := apis.GetRegionFromZone(providerSpec.Zone)
+
+	if regionLabel, ok := server.Labels["topology.kubernetes.io/region"]; !ok || region != regionLabel {
+		isCleanupAvailable = false
+	}
+
+	if zoneLabel, ok := server.Labels["topology.kubernetes.io/zone"]; !ok || !slices.Contains(apis.GetZones(providerSpec), zoneLabel) {
+		isCleanupAvailable = false
+	}
+
+	if isCleanupAvailable {
+		client := apis.GetClientForToken(string(req.Secret.Data["token"]))
+		resultData := ctx.Value(CtxWrapDataKey("MethodData")).(*CreateMachineMethodData)
+
+		resultData.ServerID = server.ID
+
+		if providerSpec.FloatingPoolName != "" {
+			name := fmt.Sprintf("%s-%s-ipv4", providerSpec.FloatingPoolName, machine.Name)
+
+			floatingIP, _, _ := client.FloatingIP.GetByName(ctx, name)
+			if floatingIP != nil {
+				resultData.FloatingIPID = floatingIP.ID
+			}
+		}
+
+		for index, volumeSpec := range providerSpec.Volumes {
+			volume, _, _ := client.Volume.GetByName(ctx, apis.GetVolumeName(&volumeSpec, machine.Name, index))
+			if volume != nil && volume.Server != nil && volume.Server.ID == server.ID {
+				resultData.VolumeIDs = append(resultData.VolumeIDs, volume.ID)
+			}
+		}
+	}
+
+	return isCleanupAvailable
+}
+
+// createMachineOnErrorCleanup cleans up a failed machine creation request
+//
+// PARAMETERS
+// ctx context.Context              Execution context
+// req *driver.CreateMachineRequest The create request for VM creation
+// err error                        Error encountered
+func (p *MachineProvider) createMachineOnErrorCleanup(ctx context.Context, req *driver.CreateMachineRequest) {
+	client := apis.GetClientForToken(string(req.Secret.Data["token"]))
+	resultData := ctx.Value(CtxWrapDataKey("MethodData")).(*CreateMachineMethodData)
+
+	for _, volumeID := range resultData.VolumeIDs {
+		volume, _, _ := client.Volume.GetByID(ctx, volumeID)
+		if nil != volume {
+			_ = p.deleteMachineVolume(ctx, client, volume, apis.DeletionPolicyDelete)
+		}
+	}
+
+	var server *hcloud.Server
+	if resultData.ServerID != 0 {
+		server, _, _ = client.Server.GetByID(ctx, resultData.ServerID)
+	} else {
+		server, _, _ = client.Server.GetByName(ctx, req.Machine.Name)
+	}
+	if nil != server {
+		deleteResult, _, err := client.Server.DeleteWithResult(ctx, server)
+		if err == nil {
+			_ = apis.WaitForActions(ctx, client, deleteResult.Action)
+		}
+	}
+
+	if resultData.FloatingIPID != 0 {
+		floatingIP, _, _ := client.FloatingIP.GetByID(ctx, resultData.FloatingIPID)
+		if nil != floatingIP {
+			_, _ = client.FloatingIP.Delete(ctx, floatingIP)
+		}
+	}
+}
+
+// DeleteMachine handles a machine deletion request
+//
+// PARAMETERS
+// ctx context.Context              Execution context
+// req *driver.CreateMachineRequest The delete request for VM deletion
+func (p *MachineProvider) DeleteMachine(ctx context.Context, req *driver.DeleteMachineRequest) (*driver.DeleteMachineResponse, error) {
+	var (
+		machine      = req.Machine
+		machineClass = req.MachineClass
+		secret       = req.Secret
+	)
+
+	// Log messages to track delete request
+	klog.V(2).Infof("Machine deletion request has been received for %q", machine.Name)
+	defer klog.V(2).Infof("Machine deletion request has been processed for %q", machine.Name)
+
+	client := apis.GetClientForToken(string(secret.Data["token"]))
+
+	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
+	if err != nil {
+		return nil, getStatusForError(codes.InvalidArgument, err)
+	}
+
+	var server *hcloud.Server
+	if machine.Spec.ProviderID != "" {
+		serverID, err := transcoder.DecodeServerIDFromProviderID(machine.Spec.ProviderID)
+		if err != nil {
+			return nil, getStatusForError(codes.InvalidArgument, err)
+		}
+
+		server, _, err = client.Server.GetByID(ctx, serverID)
+		if err != nil {
+			return nil, getStatusForServerError(codes.InvalidArgument, err)
+		}
+	} else {
+		server, _, err = client.Server.GetByName(ctx, machine.Name)
+	}
+	if err != nil {
+		return nil, getStatusForServerError(codes.InvalidArgument, err)
+	}
+
+	if server == nil {
+		klog.V(3).Infof("VM %s does not exist", machine.Name)
+	} else {
+		err = p.deleteMachineServer(ctx, client, providerSpec, server)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Resources of the machine are released once the server is gone to never leave a server behind without them
+	for index, volumeSpec := range providerSpec.Volumes {
+		volume, _, err := client.Volume.GetByName(ctx, apis.GetVolumeName(&volumeSpec, machine.Name, index))
+		if err != nil {
+			return nil, getStatusForError(codes.Internal, err)
+		} else if nil != volume {
+			err = p.deleteMachineVolume(ctx, client, volume, volumeSpec.DeletionPolicy)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if providerSpec.FloatingPoolName != "" {
+		name := fmt.Sprintf("%s-%s-ipv4", providerSpec.FloatingPoolName, machine.Name)
+
+		floatingIP, _, err := client.FloatingIP.GetByName(ctx, name)
+		if err != nil {
+			return nil, getStatusForError(codes.Internal, err)
+		} else if nil != floatingIP {
+			_, err = client.FloatingIP.Delete(ctx, floatingIP)
+			if err != nil {
+				return nil, getStatusForError(codes.Unavailable, err)
+			}
+		}
+	}
+
+	return &driver.DeleteMachineResponse{}, nil
+}
+
+// deleteMachineServer deletes the server given and waits for the deletion to complete
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// server       *hcloud.Server     Server to delete
+func (p *MachineProvider) deleteMachineServer(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, server *hcloud.Server) error {
+	var err error
+
+	if providerSpec.Shutdown != nil {
+		err = p.shutdownServer(ctx, client, providerSpec, server)
+		if err != nil {
+			return err
+		}
+	}
+
+	if providerSpec.PublicNet != nil {
+		err = p.applyPrimaryIPDeletionPolicy(ctx, client, server.PublicNet.IPv4.ID, providerSpec.PublicNet.IPv4Pool)
+		if err != nil {
+			return err
+		}
+
+		err = p.applyPrimaryIPDeletionPolicy(ctx, client, server.PublicNet.IPv6.ID, providerSpec.PublicNet.IPv6Pool)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Protected servers are unprotected first as MCM-driven deletions must always succeed
+	if server.Protection.Delete {
+		err = p.changeServerProtection(ctx, client, server, &apis.ProtectionSpec{})
+		if err != nil {
+			return getStatusForError(codes.Unavailable, err)
+		}
+	}
+
+	deleteResult, _, err := client.Server.DeleteWithResult(ctx, server)
+	if err != nil {
+		return getStatusForServerError(codes.Unavailable, err)
+	}
+
+	err = apis.WaitForActions(ctx, client, deleteResult.Action)
+	if err != nil {
+		return status.Error(codes.Unavailable, fmt.Sprintf("Deletion of server %q failed: %s", server.Name, err))
+	}
+
+	if server.PlacementGroup != nil {
+		p.deleteUnusedPlacementGroup(ctx, client, server.PlacementGroup.ID, server.ID)
+	}
+
+	return nil
+}
+
+// initializeMachineProtection applies the protection requested to the server given
+//
+// PARAMETERS
+// ctx        context.Context      Execution context
+// client     *hcloud.Client       HCloud client
+// protection *apis.ProtectionSpec Protection specification
+// server     *hcloud.Server       Server to protect
+func (p *MachineProvider) initializeMachineProtection(ctx context.Context, client *hcloud.Client, protection *apis.ProtectionSpec, server *hcloud.Server) error {
+	if server.Protection.Delete == protection.Delete && server.Protection.Rebuild == protection.Rebuild {
+		return nil
+	}
+
+	err := p.changeServerProtection(ctx, client, server, protection)
+	if err != nil {
+		return getStatusForError(codes.Uninitialized, err)
+	}
+
+	return nil
+}
+
+// changeServerProtection changes the protection of the server given and waits for the change to complete
+//
+// PARAMETERS
+// ctx        context.Context      Execution context
+// client     *hcloud.Client       HCloud client
+// server     *hcloud.Server       Server to change the protection for
+// protection *apis.ProtectionSpec Protection to apply
+func (p *MachineProvider) changeServerProtection(ctx context.Context, client *hcloud.Client, server *hcloud.Server, protection *apis.ProtectionSpec) error {
+	action, _, err := client.Server.ChangeProtection(ctx, server, hcloud.ServerChangeProtectionOpts{
+		Delete:  &protection.Delete,
+		Rebuild: &protection.Rebuild,
+	})
+	if err != nil {
+		return err
+	}
+
+	return apis.WaitForActions(ctx, client, action)
+}
+
+// shutdownServer shuts the running server given down gracefully. The server is powered off if it does not shut down
+// within the timeout configured.
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// server       *hcloud.Server     Server to shut down
+func (p *MachineProvider) shutdownServer(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, server *hcloud.Server) error {
+	if server.Status != hcloud.ServerStatusRunning {
+		return nil
+	}
+
+	timeout, err := apis.GetShutdownTimeout(providerSpec)
+	if err != nil {
+		return getStatusForError(codes.InvalidArgument, err)
+	}
+
+	action, _, err := client.Server.Shutdown(ctx, server)
+	if err != nil {
+		return getStatusForError(codes.Unavailable, err)
+	}
+
+	err = apis.WaitForActions(ctx, client, action)
+	if err == nil {
+		_, err = apis.WaitForServerStatus(ctx, client, server, hcloud.ServerStatusOff, timeout)
+		if err == nil {
+			return nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		return getStatusForError(codes.DeadlineExceeded, err)
+	}
+
+	klog.V(2).Infof("Server %q did not shut down gracefully and is powered off: %s", server.Name, err)
+
+	action, _, err = client.Server.Poweroff(ctx, server)
+	if err != nil {
+		return getStatusForError(codes.Unavailable, err)
+	}
+
+	err = apis.WaitForActions(ctx, client, action)
+	if err != nil {
+		return getStatusForError(codes.Unavailable, err)
+	}
+
+	return nil
+}
+
+// deleteMachineVolume detaches the volume given and deletes it afterwards if requested by the deletion policy
+//
+// PARAMETERS
+// ctx            context.Context     Execution context
+// client         *hcloud.Client      HCloud client
+// volume         *hcloud.Volume      Volume to detach and delete
+// deletionPolicy apis.DeletionPolicy Deletion policy of the volume
+func (p *MachineProvider) deleteMachineVolume(ctx context.Context, client *hcloud.Client, volume *hcloud.Volume, deletionPolicy apis.DeletionPolicy) error {
+	// Volumes created without the retain label or retained after a change of the policy are labelled before they are
+	// detached to never be collected as orphans
+	if deletionPolicy == apis.DeletionPolicyRetain && volume.Labels["storage.hcloud.mcm.gardener.cloud/retain"] != "true" {
+		labels := maps.Clone(volume.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+
+		labels["storage.hcloud.mcm.gardener.cloud/retain"] = "true"
+
+		_, _, err := client.Volume.Update(ctx, volume, hcloud.VolumeUpdateOpts{Labels: labels})
+		if err != nil {
+			return getStatusForError(codes.Unavailable, err)
+		}
+	}
+
+	if volume.Server != nil {
+		action, _, err := client.Volume.Detach(ctx, volume)
+		if err != nil {
+			return getStatusForError(codes.Unavailable, err)
+		}
+
+		_, err = apis.WaitForActionsAndGetVolume(ctx, client, volume, action)
+		if err != nil {
+			return getStatusForError(codes.Unavailable, err)
+		}
+	}
+
+	if deletionPolicy == apis.DeletionPolicyRetain {
+		return nil
+	}
+
+	_, err := client.Volume.Delete(ctx, volume)
+	if err != nil {
+		return getStatusForError(codes.Unavailable, err)
+	}
+
+	return nil
+}
+
+// deleteUnusedPlacementGroup deletes the auto-created placement group given if the deleted server was its last member.
+// Errors are logged only as the deletion of the placement group is best effort.
+//
+// PARAMETERS
+// ctx              context.Context Execution context
+// client           *hcloud.Client  HCloud client
+// placementGroupID int             Placement group ID
+// serverID         int             ID of the deleted server
+func (p *MachineProvider) deleteUnusedPlacementGroup(ctx context.Context, client *hcloud.Client, placementGroupID, serverID int) {
+	placementGroup, _, err := client.PlacementGroup.GetByID(ctx, placementGroupID)
+	if err != nil {
+		klog.Warningf("Failed to get placement group %d: %s", placementGroupID, err)
+		return
+	} else if placementGroup == nil || placementGroup.Labels["placement.hcloud.mcm.gardener.cloud/auto-created"] != "true" {
+		return
+	}
+
+	for _, memberID := range placementGroup.Servers {
+		if memberID != serverID {
+			return
+		}
+	}
+
+	_, err = client.PlacementGroup.Delete(ctx, placementGroup)
+	if err != nil {
+		klog.Warningf("Failed to delete unused placement group %s: %s", placementGroup.Name, err)
+	}
+}
+
+// applyPrimaryIPDeletionPolicy configures the primary IP given to be deleted with the server if requested by the pool deletion policy
+//
+// PARAMETERS
+// ctx         context.Context         Execution context
+// client      *hcloud.Client          HCloud client
+// primaryIPID int                     Primary IP ID
+// pool        *apis.PrimaryIPPoolSpec Primary IP pool specification
+func (p *MachineProvider) applyPrimaryIPDeletionPolicy(ctx context.Context, client *hcloud.Client, primaryIPID int, pool *apis.PrimaryIPPoolSpec) error {
+	if pool == nil || primaryIPID == 0 {
+		return nil
+	}
+
+	primaryIP, _, err := client.PrimaryIP.GetByID(ctx, primaryIPID)
+	if err != nil {
+		return getStatusForError(codes.Internal, err)
+	} else if primaryIP == nil {
+		return nil
+	}
+
+	autoDelete := pool.DeletionPolicy == apis.DeletionPolicyDelete
+
+	if primaryIP.AutoDelete != autoDelete {
+		_, _, err = client.PrimaryIP.Update(ctx, primaryIP, hcloud.PrimaryIPUpdateOpts{AutoDelete: &autoDelete})
+		if err != nil {
+			return getStatusForError(codes.Unavailable, err)
+		}
+	}
+
+	return nil
+}
+
+// GetMachineStatus handles a machine get status request
+//
+// PARAMETERS
+// ctx context.Context              Execution context
+// req *driver.CreateMachineRequest The get request for VM info
+func (p *MachineProvider) GetMachineStatus(ctx context.Context, req *driver.GetMachineStatusRequest) (*driver.GetMachineStatusResponse, error) {
+	var (
+		err          error
+		machine      = req.Machine
+		secret       = req.Secret
+		machineClass = req.MachineClass
+		server       *hcloud.Server
+		serverID     int
+	)
+
+	// Log messages to track start and end of request
+	klog.V(2).Infof("Get request has been received for %q", machine.Name)
+	defer klog.V(2).Infof("Machine get request has been processed successfully for %q", machine.Name)
+
+	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
+	if err != nil {
+		return nil, getStatusForError(codes.InvalidArgument, err)
+	}
+
+	client := apis.GetClientForToken(string(secret.Data["token"]))
+
+	// Handle case where machine lookup occurs with empty provider ID
+	if machine.Spec.ProviderID == "" {
+		server, _, err = client.Server.GetByName(ctx, machine.Name)
+
+		if server == nil {
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("Provider ID for machine %q is not defined (%q)", machine.Name, err))
+		}
+
+		serverID = server.ID
+	} else {
+		serverID, err := transcoder.DecodeServerIDFromProviderID(machine.Spec.ProviderID)
+		if err != nil {
+			return nil, getStatusForError(codes.InvalidArgument, err)
+		}
+
+		server, _, err = client.Server.GetByID(ctx, serverID)
+		if err != nil {
+			return nil, getStatusForServerError(codes.InvalidArgument, err)
+		}
+	}
+
+	if err != nil {
+		return nil, getStatusForError(codes.Unavailable, err)
+	} else if server == nil {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("VM %s (%d) does not exist", machine.Name, serverID))
+	}
+
+	providerID := transcoder.EncodeProviderID(getServerZone(providerSpec, server), server.ID)
+	response := &driver.GetMachineStatusResponse{ProviderID: providerID, NodeName: server.Name}
+
+	err = p.checkServerHealth(ctx, client, providerSpec, machine, server)
+	if err != nil {
+		return response, err
+	}
+
+	// Servers not running yet are reported as uninitialized to let InitializeMachine await and power them on
+	unexpectedState := getUnexpectedServerState(providerSpec, server)
+
+	// Networks and volumes are only checked while the machine is created to save API calls of each status request
+	if unexpectedState == "" && isMachineCreating(machine) {
+		networkSpecs := apis.GetNetworkSpecs(providerSpec)
+
+		networks, err := p.getNetworks(ctx, client, networkSpecs)
+		if err != nil {
+			return response, err
+		}
+
+		unexpectedState = getUnexpectedNetworksState(networkSpecs, networks, server)
+
+		if unexpectedState == "" {
+			unexpectedState, err = p.getUnexpectedVolumeState(ctx, client, providerSpec, machine.Name, server)
+			if err != nil {
+				return response, getStatusForError(codes.Uninitialized, err)
+			}
+		}
+	}
+
+	if unexpectedState != "" {
+		return response, status.Error(codes.Uninitialized, fmt.Sprintf("Server state does not match expectation: %s", unexpectedState))
+	}
+
+	return response, nil
+}
+
+// checkServerHealth returns an Unavailable error if the server given is locked, has stuck actions, is migrating or in
+// an unknown state. Servers of running machines stopped unexpectedly are reported as unavailable as well unless they
+// are powered on again by InitializeMachine as requested by the provider spec. Servers with running actions are
+// reported as uninitialized to let InitializeMachine await them.
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// machine      *v1alpha1.Machine  Machine of the server
+// server       *hcloud.Server     Server to check
+func (p *MachineProvider) checkServerHealth(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, machine *v1alpha1.Machine, server *hcloud.Server) error {
+	if server.Locked {
+		return status.Error(codes.Unavailable, fmt.Sprintf("Server %q is locked", server.Name))
+	}
+
+	switch server.Status {
+	case hcloud.ServerStatusMigrating, hcloud.ServerStatusUnknown:
+		return status.Error(codes.Unavailable, fmt.Sprintf("Server %q is %s", server.Name, server.Status))
+	case hcloud.ServerStatusOff:
+		if isMachineRunning(machine) && !providerSpec.AutoPowerOn {
+			return status.Error(codes.Unavailable, fmt.Sprintf("Server %q is off unexpectedly", server.Name))
+		}
+	}
+
+	runningActions, err := apis.GetRunningServerActions(ctx, client, server)
+	if err != nil {
+		return getStatusForError(codes.Unavailable, err)
+	}
+
+	for _, action := range runningActions {
+		if time.Since(action.Started) > apis.ActionWaitTimeout {
+			return status.Error(codes.Unavailable, fmt.Sprintf("Action %s of server %q is stuck since %s", action.Command, server.Name, action.Started.Format(time.RFC3339)))
+		}
+	}
+
+	if len(runningActions) > 0 {
+		return status.Error(codes.Uninitialized, fmt.Sprintf("Server %q has %d running actions", server.Name, len(runningActions)))
+	}
+
+	return nil
+}
+
+// isMachineCreating returns true if the machine given is still created and initialized by MCM.
+//
+// PARAMETERS
+// machine *v1alpha1.Machine Machine to check
+func isMachineCreating(machine *v1alpha1.Machine) bool {
+	phase := machine.Status.CurrentStatus.Phase
+	return machine.DeletionTimestamp == nil && (phase == "" || phase == v1alpha1.MachineCrashLoopBackOff)
+}
+
+// isMachineRunning returns true if the machine given has been running and its server is expected to be powered on.
+//
+// PARAMETERS
+// machine *v1alpha1.Machine Machine to check
+func isMachineRunning(machine *v1alpha1.Machine) bool {
+	phase := machine.Status.CurrentStatus.Phase
+	return machine.DeletionTimestamp == nil && (phase == v1alpha1.MachineRunning || phase == v1alpha1.MachineUnknown)
+}
+
+// ListMachines lists all the machines possibilly created by a providerSpec
+//
+// PARAMETERS
+// ctx context.Context              Execution context
+// req *driver.CreateMachineRequest The request object to get a list of VMs belonging to a machineClass
+func (p *MachineProvider) ListMachines(ctx context.Context, req *driver.ListMachinesRequest) (*driver.ListMachinesResponse, error) {
+	var (
+		machineClass = req.MachineClass
+		secret       = req.Secret
+	)
+
+	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
+	if err != nil {
+		return nil, getStatusForError(codes.InvalidArgument, err)
 	}
 
 	// Log messages to track start and end of request
@@ -494,14 +1471,23 @@ func (p *MachineProvider) ListMachines(ctx context.Context, req *driver.ListMach
 	defer klog.V(2).Infof("List machines request has been processed for %q", machineClass.Name)
 
 	client := apis.GetClientForToken(string(secret.Data["token"]))
-	zone := providerSpec.Zone
+
+	zoneSelector := fmt.Sprintf("topology.kubernetes.io/zone=%s", url.QueryEscape(providerSpec.Zone))
+	if len(providerSpec.FallbackZones) > 0 {
+		var zones []string
+		for _, zone := range apis.GetZones(providerSpec) {
+			zones = append(zones, url.QueryEscape(zone))
+		}
+
+		zoneSelector = fmt.Sprintf("topology.kubernetes.io/zone in (%s)", strings.Join(zones, ","))
+	}
 
 	listopts := hcloud.ServerListOpts{
 		ListOpts: hcloud.ListOpts{
 			LabelSelector: fmt.Sprintf(
-				"mcm.gardener.cloud/cluster=%s,mcm.gardener.cloud/role=node,topology.kubernetes.io/zone=%s",
+				"mcm.gardener.cloud/cluster=%s,mcm.gardener.cloud/role=node,%s",
 				url.QueryEscape(providerSpec.Cluster),
-				url.QueryEscape(zone),
+				zoneSelector,
 			),
 			PerPage: 50,
 		},
@@ -509,13 +1495,16 @@ func (p *MachineProvider) ListMachines(ctx context.Context, req *driver.ListMach
 
 	servers, err := client.Server.AllWithOpts(ctx, listopts)
 	if err != nil {
-		return nil, status.Error(codes.Unavailable, err.Error())
+		return nil, getStatusForError(codes.Unavailable, err)
 	}
 
+	// Machines are listed periodically for all machine classes and trigger the collection of orphaned resources
+	p.orphanCollector.Trigger(client, providerSpec.Cluster)
+
 	listOfVMs := make(map[string]string)
 
 	for _, server := range servers {
-		listOfVMs[transcoder.EncodeProviderID(zone, server.ID)] = server.Name
+		listOfVMs[transcoder.EncodeProviderID(getServerZone(providerSpec, server), server.ID)] = server.Name
 	}
 
 	return &driver.ListMachinesResponse{MachineList: listOfVMs}, nil
@@ -531,10 +1520,368 @@ func (p *MachineProvider) GetVolumeIDs(_ context.Context, req *driver.GetVolumeI
 	klog.V(2).Infof("GetVolumeIDs request has been received for %q", req.PVSpecs)
 	defer klog.V(2).Infof("GetVolumeIDs request has been processed successfully for %q", req.PVSpecs)
 
-	return &driver.GetVolumeIDsResponse{}, status.Error(codes.Unimplemented, "")
+	volumeIDs := []string{}
+
+	for _, pvSpec := range req.PVSpecs {
+		if pvSpec == nil {
+			continue
+		}
+
+		volumeID, ok := apis.GetVolumeIDFromPVSpec(pvSpec)
+		if ok {
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+	}
+
+	return &driver.GetVolumeIDsResponse{VolumeIDs: volumeIDs}, nil
+}
+
+// InitializeMachine handles VM initialization for hcloud VM's
+//
+// PARAMETERS
+// ctx context.Context                  Execution context
+// req *driver.InitializeMachineRequest The initialization request for the VM
+func (p *MachineProvider) InitializeMachine(ctx context.Context, req *driver.InitializeMachineRequest) (*driver.InitializeMachineResponse, error) {
+	var (
+		machine      = req.Machine
+		machineClass = req.MachineClass
+		secret       = req.Secret
+		server       *hcloud.Server
+	)
+
+	// Log messages to track request
+	klog.V(2).Infof("Machine initialization request has been received for %q", machine.Name)
+	defer klog.V(2).Infof("Machine initialization request has been processed for %q", machine.Name)
+
+	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
+	if err != nil {
+		return nil, getStatusForError(codes.InvalidArgument, err)
+	}
+
+	client := apis.GetClientForToken(string(secret.Data["token"]))
+
+	if machine.Spec.ProviderID != "" {
+		serverID, err := transcoder.DecodeServerIDFromProviderID(machine.Spec.ProviderID)
+		if err != nil {
+			return nil, getStatusForError(codes.InvalidArgument, err)
+		}
+
+		server, _, err = client.Server.GetByID(ctx, serverID)
+		if err != nil {
+			return nil, getStatusForServerError(codes.Uninitialized, err)
+		}
+	} else {
+		server, _, err = client.Server.GetByName(ctx, machine.Name)
+		if err != nil {
+			return nil, getStatusForServerError(codes.Uninitialized, err)
+		}
+	}
+
+	if server == nil {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("VM %s does not exist", machine.Name))
+	}
+
+	server, err = apis.WaitForActionsAndGetServer(ctx, client, server)
+	if err != nil {
+		return nil, getStatusForError(codes.Uninitialized, err)
+	}
+
+	networkSpecs := apis.GetNetworkSpecs(providerSpec)
+
+	networks, err := p.getNetworks(ctx, client, networkSpecs)
+	if err != nil {
+		return nil, getStatusForError(codes.Uninitialized, err)
+	}
+
+	for index, network := range networks {
+		err = p.initializeMachineNetwork(ctx, client, server, network, &networkSpecs[index])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if providerSpec.FloatingPoolName != "" {
+		err = p.initializeMachineFloatingIP(ctx, client, providerSpec, machine.Name, server)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if providerSpec.Protection != nil {
+		err = p.initializeMachineProtection(ctx, client, providerSpec.Protection, server)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var powerOnAction *hcloud.Action
+
+	if hcloud.ServerStatusOff == server.Status && isMachineRunning(machine) && !providerSpec.AutoPowerOn {
+		return nil, status.Error(codes.Unavailable, fmt.Sprintf("Server %q is off unexpectedly", server.Name))
+	}
+
+	if hcloud.ServerStatusStarting != server.Status && hcloud.ServerStatusRunning != server.Status {
+		powerOnAction, _, err = client.Server.Poweron(ctx, server)
+		if err != nil {
+			return nil, getStatusForError(codes.Uninitialized, err)
+		}
+	}
+
+	server, err = apis.WaitForActionsAndGetServer(ctx, client, server, powerOnAction)
+	if err != nil {
+		return nil, getStatusForError(codes.Uninitialized, err)
+	}
+
+	unexpectedState := getUnexpectedServerState(providerSpec, server)
+
+	if unexpectedState == "" {
+		unexpectedState = getUnexpectedNetworksState(networkSpecs, networks, server)
+	}
+
+	if unexpectedState == "" {
+		unexpectedState, err = p.getUnexpectedVolumeState(ctx, client, providerSpec, machine.Name, server)
+		if err != nil {
+			return nil, getStatusForError(codes.Uninitialized, err)
+		}
+	}
+
+	if unexpectedState != "" {
+		return nil, status.Error(codes.Uninitialized, fmt.Sprintf("Server state does not match expectation: %s", unexpectedState))
+	}
+
+	response := &driver.InitializeMachineResponse{
+		ProviderID: transcoder.EncodeProviderID(getServerZone(providerSpec, server), server.ID),
+		NodeName:   server.Name,
+	}
+
+	return response, nil
+}
+
+// initializeMachineNetwork ensures that the server is attached to the network as defined by the network specification
+//
+// PARAMETERS
+// ctx         context.Context   Execution context
+// client      *hcloud.Client    HCloud client
+// server      *hcloud.Server    Server to attach
+// network     *hcloud.Network   Network to attach the server to
+// networkSpec *apis.NetworkSpec Network specification
+func (p *MachineProvider) initializeMachineNetwork(ctx context.Context, client *hcloud.Client, server *hcloud.Server, network *hcloud.Network, networkSpec *apis.NetworkSpec) error {
+	var action *hcloud.Action
+	var err error
+
+	privateNet := getServerPrivateNet(server, network)
+
+	if privateNet == nil {
+		action, err = apis.AttachServerToNetwork(ctx, client, server, network, networkSpec)
+		if err != nil {
+			return getStatusForError(codes.Uninitialized, err)
+		}
+	} else if len(networkSpec.AliasIPs) > 0 && len(privateNet.Aliases) != len(networkSpec.AliasIPs) {
+		opts := hcloud.ServerChangeAliasIPsOpts{Network: network}
+
+		for _, aliasIP := range networkSpec.AliasIPs {
+			opts.AliasIPs = append(opts.AliasIPs, net.ParseIP(aliasIP))
+		}
+
+		action, _, err = client.Server.ChangeAliasIPs(ctx, server, opts)
+		if err != nil {
+			return getStatusForError(codes.Uninitialized, err)
+		}
+	} else {
+		return nil
+	}
+
+	_, err = apis.WaitForActionsAndGetServer(ctx, client, server, action)
+	if err != nil {
+		return getStatusForError(codes.Uninitialized, err)
+	}
+
+	return nil
+}
+
+// initializeMachineFloatingIP ensures that the floating IP of the machine exists and is assigned to the server given
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// machineName  string             Machine name
+// server       *hcloud.Server     Server to assign the floating IP to
+func (p *MachineProvider) initializeMachineFloatingIP(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, machineName string, server *hcloud.Server) error {
+	name := fmt.Sprintf("%s-%s-ipv4", providerSpec.FloatingPoolName, machineName)
+
+	floatingIP, _, err := client.FloatingIP.GetByName(ctx, name)
+	if err != nil {
+		return getStatusForError(codes.Uninitialized, err)
+	}
+
+	var action *hcloud.Action
+
+	if floatingIP == nil {
+		opts := hcloud.FloatingIPCreateOpts{
+			Name:   &name,
+			Type:   hcloud.FloatingIPTypeIPv4,
+			Server: server,
+			Labels: apis.GetResourceLabels(providerSpec, map[string]string{
+				"mcm.gardener.cloud/cluster":                         providerSpec.Cluster,
+				"networking.hcloud.mcm.gardener.cloud/floating-pool": providerSpec.FloatingPoolName,
+			}),
+		}
+
+		ipResult, _, err := client.FloatingIP.Create(ctx, opts)
+		if err != nil {
+			return getStatusForError(codes.Uninitialized, err)
+		}
+
+		floatingIP = ipResult.FloatingIP
+		action = ipResult.Action
+	} else if floatingIP.Server == nil || floatingIP.Server.ID != server.ID {
+		action, _, err = client.FloatingIP.Assign(ctx, floatingIP, server)
+		if err != nil {
+			return getStatusForError(codes.Uninitialized, err)
+		}
+	}
+
+	_, err = apis.WaitForActionsAndGetFloatingIP(ctx, client, floatingIP, action)
+	if err != nil {
+		return getStatusForError(codes.Uninitialized, err)
+	}
+
+	return nil
+}
+
+// getUnexpectedServerState compares the server state with the expectation defined by the provider specification.
+// An empty string is returned if the server is fully initialized.
+//
+// PARAMETERS
+// providerSpec *apis.ProviderSpec Provider specification
+// server       *hcloud.Server     Server to check
+func getUnexpectedServerState(providerSpec *apis.ProviderSpec, server *hcloud.Server) string {
+	// For some reason our machine may not be started. This behavior was observed from time to time.
+	if server.Status != hcloud.ServerStatusRunning {
+		return fmt.Sprintf("Server is %s instead of running", server.Status)
+	}
+
+	if providerSpec.FloatingPoolName != "" && len(server.PublicNet.FloatingIPs) != 1 {
+		return "Floating IP set-up failed"
+	}
+
+	if providerSpec.Protection != nil && (server.Protection.Delete != providerSpec.Protection.Delete || server.Protection.Rebuild != providerSpec.Protection.Rebuild) {
+		return "Protection set-up failed"
+	}
+
+	if len(providerSpec.Firewalls) > 0 && len(server.PublicNet.Firewalls) == 0 {
+		return "Firewall set-up failed"
+	}
+
+	for _, firewallStatus := range server.PublicNet.Firewalls {
+		if firewallStatus.Status != hcloud.FirewallStatusApplied {
+			return fmt.Sprintf("Firewall %d is %s", firewallStatus.Firewall.ID, firewallStatus.Status)
+		}
+	}
+
+	return ""
+}
+
+// getUnexpectedVolumeState compares the volumes attached to the server with the volumes defined by the provider
+// specification. Other volumes attached to the server, e.g. by the CSI driver, are ignored. An empty string is returned
+// if all volumes are attached as expected.
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+// machineName  string             Machine name
+// server       *hcloud.Server     Server to check
+func (p *MachineProvider) getUnexpectedVolumeState(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec, machineName string, server *hcloud.Server) (string, error) {
+	for index, volumeSpec := range providerSpec.Volumes {
+		name := apis.GetVolumeName(&volumeSpec, machineName, index)
+
+		volume, _, err := client.Volume.GetByName(ctx, name)
+		if err != nil {
+			return "", err
+		} else if volume == nil {
+			return fmt.Sprintf("Volume %s does not exist", name), nil
+		} else if volume.Server == nil || volume.Server.ID != server.ID {
+			return fmt.Sprintf("Volume %s is not attached", name), nil
+		}
+	}
+
+	return "", nil
+}
+
+// getUnexpectedNetworksState compares the server network attachments with the expectation defined by the network
+// specifications. Each network is matched by its ID. An empty string is returned if all network attachments are as
+// expected.
+//
+// PARAMETERS
+// networkSpecs []apis.NetworkSpec Network specifications
+// networks     []*hcloud.Network  Networks of the network specifications
+// server       *hcloud.Server     Server to check
+func getUnexpectedNetworksState(networkSpecs []apis.NetworkSpec, networks []*hcloud.Network, server *hcloud.Server) string {
+	for index, network := range networks {
+		if unexpectedState := getUnexpectedNetworkState(&networkSpecs[index], network, server); unexpectedState != "" {
+			return unexpectedState
+		}
+	}
+
+	return ""
+}
+
+// getUnexpectedNetworkState compares the server network attachment with the expectation defined by the network specification.
+// An empty string is returned if the network attachment is as expected.
+//
+// PARAMETERS
+// networkSpec *apis.NetworkSpec Network specification
+// network     *hcloud.Network   Network to check
+// server      *hcloud.Server    Server to check
+func getUnexpectedNetworkState(networkSpec *apis.NetworkSpec, network *hcloud.Network, server *hcloud.Server) string {
+	privateNet := getServerPrivateNet(server, network)
+
+	if privateNet == nil {
+		return fmt.Sprintf("Server is not attached to network %s", network.Name)
+	}
+
+	if networkSpec.IP != "" && !privateNet.IP.Equal(net.ParseIP(networkSpec.IP)) {
+		return fmt.Sprintf("Server IP %s in network %s does not match %s", privateNet.IP, network.Name, networkSpec.IP)
+	}
+
+	if networkSpec.IPRange != "" {
+		_, ipRange, err := net.ParseCIDR(networkSpec.IPRange)
+		if err != nil || !ipRange.Contains(privateNet.IP) {
+			return fmt.Sprintf("Server IP %s in network %s is not in IP range %s", privateNet.IP, network.Name, networkSpec.IPRange)
+		}
+	}
+
+	for _, aliasIP := range networkSpec.AliasIPs {
+		found := false
+
+		for _, alias := range privateNet.Aliases {
+			if alias.Equal(net.ParseIP(aliasIP)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Sprintf("Alias IP %s in network %s is not assigned", aliasIP, network.Name)
+		}
+	}
+
+	return ""
 }
 
-// InitializeMachine handles VM initialization for hcloud VM's. Currently, un-implemented.
-func (p *MachineProvider) InitializeMachine(_ context.Context, _ *driver.InitializeMachineRequest) (*driver.InitializeMachineResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "Hcloud Provider does not yet implement InitializeMachine")
+// getServerPrivateNet returns the private network attachment of the server for the network given.
+//
+// PARAMETERS
+// server  *hcloud.Server  Server to search
+// network *hcloud.Network Network to search for
+func getServerPrivateNet(server *hcloud.Server, network *hcloud.Network) *hcloud.ServerPrivateNet {
+	for index := range server.PrivateNet {
+		if server.PrivateNet[index].Network != nil && server.PrivateNet[index].Network.ID == network.ID {
+			return &server.PrivateNet[index]
+		}
+	}
+
+	return nil
 }
@@ -19,17 +19,37 @@ package hcloud
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	mcmfake "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/fake"
+	mcminformers "github.com/gardener/machine-controller-manager/pkg/client/informers/externalversions"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	machinecontroller "github.com/gardener/machine-controller-manager/pkg/util/provider/machinecontroller"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/options"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis"
 	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis/mock"
+	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis/transcoder"
 )
 
 var provider *MachineProvider
@@ -51,12 +71,19 @@ var _ = Describe("MachineController", func() {
 		mockTestEnv = mock.NewMockTestEnv()
 
 		apis.SetClientForToken("dummy-token", mockTestEnv.Client)
+		mock.SetupActionsEndpointOnMux(mockTestEnv.Mux)
+		mock.SetupFirewallsEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupFloatingIPsEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupImagesEndpointOnMux(mockTestEnv.Mux)
+		mock.SetupNetworksEndpointOnMux(mockTestEnv.Mux)
+		mock.SetupPlacementGroupsEndpointOnMux(mockTestEnv.Mux)
+		mock.SetupPrimaryIPsEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupServersEndpointOnMux(mockTestEnv.Mux, true)
+		mock.SetupServerTypesEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupSshKeysEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupTestPlacementGroupEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupTestServerEndpointOnMux(mockTestEnv.Mux)
+		mock.SetupVolumesEndpointOnMux(mockTestEnv.Mux)
 	})
 
 	var _ = AfterEach(func() {
@@ -112,6 +139,47 @@ var _ = Describe("MachineController", func() {
 					errToHaveOccurred: false,
 				},
 			}),
+			Entry("is correctly executed with a primary IP pool", &data{
+				setup: setup{},
+				action: action{
+					&driver.CreateMachineRequest{
+						Machine:      mock.NewMachine(-1),
+						MachineClass: mock.NewMachineClassWithProviderSpec([]byte(mock.TestProviderSpecWithPublicNet)),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("finds no unassigned primary IP in the pool", &data{
+				setup: setup{},
+				action: action{
+					&driver.CreateMachineRequest{
+						Machine:      mock.NewMachine(-1),
+						MachineClass: mock.NewMachineClassWithProviderSpec([]byte(strings.Replace(mock.TestProviderSpecWithPublicNet, mock.TestPrimaryIPSelector, "pool=empty", 1))),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errStatus:         codes.ResourceExhausted,
+				},
+			}),
+			Entry("references an unknown firewall", &data{
+				setup: setup{},
+				action: action{
+					&driver.CreateMachineRequest{
+						Machine:      mock.NewMachine(-1),
+						MachineClass: mock.NewMachineClassWithProviderSpec([]byte(strings.Replace(mock.TestProviderSpecWithFirewalls, mock.TestFirewallName, "unknown", 1))),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errStatus:         codes.InvalidArgument,
+				},
+			}),
 
 			Entry("contains a provider ID", &data{
 				setup: setup{},
@@ -206,6 +274,32 @@ var _ = Describe("MachineController", func() {
 					errToHaveOccurred: false,
 				},
 			}),
+			Entry("is correctly executed with volumes", &data{
+				setup: setup{},
+				action: action{
+					&driver.DeleteMachineRequest{
+						Machine:      mock.NewMachine(mock.TestServerID),
+						MachineClass: mock.NewMachineClassWithProviderSpec([]byte(mock.TestProviderSpecWithVolumes)),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("is correctly executed with a primary IP pool", &data{
+				setup: setup{},
+				action: action{
+					&driver.DeleteMachineRequest{
+						Machine:      mock.NewMachine(mock.TestServerID),
+						MachineClass: mock.NewMachineClassWithProviderSpec([]byte(mock.TestProviderSpecWithPublicNet)),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
 
 			Entry("contains no provider ID", &data{
 				setup: setup{},
@@ -237,6 +331,113 @@ var _ = Describe("MachineController", func() {
 		)
 	})
 
+	Describe("#InitializeMachine", func() {
+		type setup struct {
+		}
+
+		type action struct {
+			machineRequest *driver.InitializeMachineRequest
+		}
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+		}
+
+		type data struct {
+			setup  setup
+			action action
+			expect expect
+		}
+
+		DescribeTable("##table",
+			func(data *data) {
+				ctx := context.Background()
+				_, err := provider.InitializeMachine(ctx, data.action.machineRequest)
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+				} else {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			},
+
+			Entry("is correctly executed", &data{
+				setup: setup{},
+				action: action{
+					&driver.InitializeMachineRequest{
+						Machine:      mock.NewMachine(mock.TestServerID),
+						MachineClass: mock.NewMachineClass(),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("is correctly executed with networks", &data{
+				setup: setup{},
+				action: action{
+					&driver.InitializeMachineRequest{
+						Machine:      mock.NewMachine(mock.TestServerID),
+						MachineClass: mock.NewMachineClassWithProviderSpec([]byte(mock.TestProviderSpecWithNetworks)),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("detects an unexpected network IP", &data{
+				setup: setup{},
+				action: action{
+					&driver.InitializeMachineRequest{
+						Machine:      mock.NewMachine(mock.TestServerID),
+						MachineClass: mock.NewMachineClassWithProviderSpec([]byte(strings.Replace(mock.TestProviderSpecWithNetworks, "\"ipRange\":\"10.0.0.0/24\"", "\"ip\":\"10.0.0.3\"", 1))),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errStatus:         codes.Uninitialized,
+				},
+			}),
+
+			Entry("contains no provider ID", &data{
+				setup: setup{},
+				action: action{
+					&driver.InitializeMachineRequest{
+						Machine:      mock.NewMachine(-1),
+						MachineClass: mock.NewMachineClass(),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errStatus:         codes.NotFound,
+				},
+			}),
+			Entry("contains an invalid provider ID", &data{
+				setup: setup{},
+				action: action{
+					&driver.InitializeMachineRequest{
+						Machine:      mock.ManipulateMachine(mock.NewMachine(mock.TestServerID), map[string]interface{}{"Spec.ProviderID": "test:///invalid"}),
+						MachineClass: mock.NewMachineClass(),
+						Secret:       providerSecret,
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errStatus:         codes.InvalidArgument,
+				},
+			}),
+		)
+	})
+
 	Describe("#GetMachineStatus", func() {
 		type setup struct {
 		}
@@ -380,31 +581,2022 @@ var _ = Describe("MachineController", func() {
 		)
 	})
 
-	Describe("#GetVolumeIDs", func() {
-		It("is not implemented", func() {
+	Describe("machine lifecycle", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		It("should create, list and delete a machine", func() {
 			ctx := context.Background()
+			machine := mock.NewMachine(-1)
+			machineClass := mock.NewMachineClass()
 
-			req := &driver.GetVolumeIDsRequest{
-				PVSpecs: []*corev1.PersistentVolumeSpec{
-					{
-						Capacity:                      map[corev1.ResourceName]resource.Quantity{},
-						PersistentVolumeSource:        corev1.PersistentVolumeSource{},
-						AccessModes:                   []corev1.PersistentVolumeAccessMode{},
-						ClaimRef:                      &corev1.ObjectReference{},
-						PersistentVolumeReclaimPolicy: "",
-						StorageClassName:              "",
-						MountOptions:                  []string{},
-						NodeAffinity:                  &corev1.VolumeNodeAffinity{},
-					},
-				},
-			}
+			createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(createResp.NodeName).To(Equal(machine.Name))
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers).To(HaveLen(1))
+			Expect(servers[0].Name).To(Equal(machine.Name))
+			Expect(servers[0].Status).To(Equal("off"))
+			Expect(servers[0].PlacementGroup).NotTo(BeNil())
+			Expect(servers[0].PlacementGroup.Servers).To(Equal([]int{servers[0].ID}))
+
+			machine.Spec.ProviderID = createResp.ProviderID
+
+			_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTestEnv.API.Servers()[0].Status).To(Equal("running"))
+
+			_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			listResp, err := provider.ListMachines(ctx, &driver.ListMachinesRequest{
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listResp.MachineList).To(HaveKeyWithValue(createResp.ProviderID, machine.Name))
+
+			_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTestEnv.API.Servers()).To(BeEmpty())
+
+			_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).To(HaveOccurred())
+
+			errStatus, ok := err.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(codes.NotFound))
+		})
+
+		It("should only check the volumes of the provider spec attached to a server", func() {
+			ctx := context.Background()
+			machine := mock.NewMachine(-1)
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"Volumes": []apis.VolumeSpec{{Size: 10}},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			machineClass := mock.NewMachineClassWithProviderSpec(providerSpec)
+
+			createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			machine.Spec.ProviderID = createResp.ProviderID
+
+			_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Volumes of persistent volume claims are attached to the node by the CSI driver
+			serverID := fakeTestEnv.API.Servers()[0].ID
+			fakeTestEnv.API.AddVolume(schema.Volume{Name: "pvc-test", Size: 10, Server: &serverID, Labels: map[string]string{}})
+
+			_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
 
-			_, err := provider.GetVolumeIDs(ctx, req)
+			volume, _, err := fakeTestEnv.Client.Volume.GetByName(ctx, fmt.Sprintf("%s-volume-0", machine.Name))
+			Expect(err).NotTo(HaveOccurred())
+
+			action, _, err := fakeTestEnv.Client.Volume.Detach(ctx, volume)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apis.WaitForActions(ctx, fakeTestEnv.Client, action)).To(Succeed())
+
+			_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is not attached"))
+
+			errStatus, ok := err.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(codes.Uninitialized))
+		})
+
+		It("should report servers not attached to the networks of the provider spec as Uninitialized", func() {
+			ctx := context.Background()
+
+			fakeTestEnv.API.AddNetwork(schema.Network{Name: "configured", IPRange: "10.0.0.0/16"})
+			unrelatedNetworkID := fakeTestEnv.API.AddNetwork(schema.Network{Name: "unrelated", IPRange: "10.1.0.0/16"})
+
+			serverID := fakeTestEnv.API.AddServer(schema.Server{
+				Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+				Status:     "running",
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+				Labels:     map[string]string{},
+				PrivateNet: []schema.ServerPrivateNet{{Network: unrelatedNetworkID, IP: "10.1.0.2", AliasIPs: []string{}}},
+			})
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"Networks": []apis.NetworkSpec{{Name: "configured"}},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      mock.NewMachine(serverID),
+				MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+				Secret:       providerSecret,
+			})
 			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Server is not attached to network configured"))
 
 			errStatus, ok := err.(*status.Status)
 			Expect(ok).To(BeTrue())
-			Expect(errStatus.Code()).To(Equal(codes.Unimplemented))
+			Expect(errStatus.Code()).To(Equal(codes.Uninitialized))
+		})
+
+		It("should keep retained volumes created without the retain label from being collected as orphans", func() {
+			ctx := context.Background()
+			machine := mock.NewMachine(mock.TestServerID)
+
+			serverID := fakeTestEnv.API.AddServer(schema.Server{
+				ID:         mock.TestServerID,
+				Name:       machine.Name,
+				Status:     "running",
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+				Labels:     map[string]string{"mcm.gardener.cloud/cluster": mock.TestCluster, "mcm.gardener.cloud/role": "node"},
+			})
+
+			// Volumes created with the "Delete" policy or before the retain label was introduced lack the label
+			fakeTestEnv.API.AddVolume(schema.Volume{
+				Name:    fmt.Sprintf("%s-volume-0", machine.Name),
+				Size:    10,
+				Server:  &serverID,
+				Labels:  map[string]string{"mcm.gardener.cloud/cluster": mock.TestCluster, "storage.hcloud.mcm.gardener.cloud/machine": machine.Name},
+				Created: time.Now().Add(-2 * time.Hour),
+			})
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"Volumes": []apis.VolumeSpec{{Size: 10, DeletionPolicy: apis.DeletionPolicyRetain}},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+				Machine:      machine,
+				MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTestEnv.API.Servers()).To(BeEmpty())
+
+			orphans, err := NewOrphanCollector().Collect(ctx, fakeTestEnv.Client, mock.TestCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(orphans).To(BeEmpty())
+
+			volumes := fakeTestEnv.API.Volumes()
+			Expect(volumes).To(HaveLen(1))
+			Expect(volumes[0].Server).To(BeNil())
+			Expect(volumes[0].Labels).To(HaveKeyWithValue("storage.hcloud.mcm.gardener.cloud/retain", "true"))
+			Expect(volumes[0].Labels).To(HaveKeyWithValue("storage.hcloud.mcm.gardener.cloud/machine", machine.Name))
+		})
+
+		It("should add the labels of the provider spec to all resources of a machine", func() {
+			ctx := context.Background()
+			machine := mock.NewMachine(-1)
+
+			userLabels := map[string]string{"team": "a", "environment": "production"}
+
+			pooledIPID := fakeTestEnv.API.AddPrimaryIP(schema.PrimaryIP{
+				Type:       "ipv4",
+				IP:         "192.0.2.1",
+				Labels:     map[string]string{"pool": "test", "team": "b"},
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+			})
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"Labels":           userLabels,
+				"FloatingPoolName": "test-pool",
+				"Volumes":          []apis.VolumeSpec{{Size: 10}},
+				"PublicNet":        &apis.PublicNetSpec{IPv4Pool: &apis.PrimaryIPPoolSpec{LabelSelector: "pool=test"}},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			machineClass := mock.NewMachineClassWithProviderSpec(providerSpec)
+
+			createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			machine.Spec.ProviderID = createResp.ProviderID
+
+			_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers).To(HaveLen(1))
+			Expect(servers[0].Labels).To(HaveKeyWithValue("team", "a"))
+			Expect(servers[0].Labels).To(HaveKeyWithValue("environment", "production"))
+			Expect(servers[0].Labels).To(HaveKeyWithValue("mcm.gardener.cloud/cluster", mock.TestCluster))
+
+			volumes := fakeTestEnv.API.Volumes()
+			Expect(volumes).To(HaveLen(1))
+			Expect(volumes[0].Labels).To(HaveKeyWithValue("team", "a"))
+			Expect(volumes[0].Labels).To(HaveKeyWithValue("storage.hcloud.mcm.gardener.cloud/machine", machine.Name))
+
+			floatingIPs := fakeTestEnv.API.FloatingIPs()
+			Expect(floatingIPs).To(HaveLen(1))
+			Expect(floatingIPs[0].Labels).To(HaveKeyWithValue("team", "a"))
+			Expect(floatingIPs[0].Labels).To(HaveKeyWithValue("networking.hcloud.mcm.gardener.cloud/floating-pool", "test-pool"))
+
+			primaryIPs := fakeTestEnv.API.PrimaryIPs()
+			Expect(primaryIPs).To(HaveLen(2))
+
+			for _, primaryIP := range primaryIPs {
+				Expect(primaryIP.AssigneeID).To(Equal(servers[0].ID))
+				Expect(primaryIP.Labels).To(HaveKeyWithValue("environment", "production"))
+
+				if primaryIP.ID == pooledIPID {
+					Expect(primaryIP.Labels).To(Equal(map[string]string{"pool": "test", "team": "b", "environment": "production"}))
+				} else {
+					Expect(primaryIP.Labels).To(Equal(map[string]string{
+						"team":                       "a",
+						"environment":                "production",
+						"mcm.gardener.cloud/cluster": mock.TestCluster,
+						"networking.hcloud.mcm.gardener.cloud/machine": machine.Name,
+					}))
+				}
+			}
+		})
+	})
+
+	Describe("machine resources", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type expect struct {
+			firewall       string
+			volume         string
+			network        string
+			networkIPRange string
+			placementGroup string
+		}
+
+		type data struct {
+			providerSpec string
+			expect       expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			fakeTestEnv.API.AddFirewall(schema.Firewall{Name: mock.TestFirewallName, Labels: map[string]string{}})
+			fakeTestEnv.API.AddNetwork(schema.Network{Name: mock.TestNetworkName, IPRange: "10.0.0.0/16"})
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#CreateMachine",
+			func(data *data) {
+				ctx := context.Background()
+				machine := mock.NewMachine(-1)
+				machineClass := mock.NewMachineClassWithProviderSpec([]byte(data.providerSpec))
+
+				createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      machine,
+					MachineClass: machineClass,
+					Secret:       providerSecret,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				machine.Spec.ProviderID = createResp.ProviderID
+
+				_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+					Machine:      machine,
+					MachineClass: machineClass,
+					Secret:       providerSecret,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				servers := fakeTestEnv.API.Servers()
+				Expect(servers).To(HaveLen(1))
+
+				server := servers[0]
+
+				if data.expect.firewall == "" {
+					Expect(server.PublicNet.Firewalls).To(BeEmpty())
+				} else {
+					firewalls, _, err := fakeTestEnv.Client.Firewall.List(ctx, hcloud.FirewallListOpts{Name: data.expect.firewall})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(firewalls).To(HaveLen(1))
+					Expect(server.PublicNet.Firewalls).To(Equal([]schema.ServerFirewall{{ID: firewalls[0].ID, Status: "applied"}}))
+				}
+
+				if data.expect.volume == "" {
+					Expect(fakeTestEnv.API.Volumes()).To(BeEmpty())
+				} else {
+					volumes := fakeTestEnv.API.Volumes()
+					Expect(volumes).To(HaveLen(1))
+					Expect(volumes[0].Name).To(Equal(data.expect.volume))
+					Expect(volumes[0].Size).To(Equal(10))
+					Expect(volumes[0].Server).NotTo(BeNil())
+					Expect(*volumes[0].Server).To(Equal(server.ID))
+					Expect(server.Volumes).To(Equal([]int{volumes[0].ID}))
+				}
+
+				if data.expect.network == "" {
+					Expect(server.PrivateNet).To(BeEmpty())
+				} else {
+					network, _, err := fakeTestEnv.Client.Network.GetByName(ctx, data.expect.network)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(network).NotTo(BeNil())
+					Expect(server.PrivateNet).To(HaveLen(1))
+					Expect(server.PrivateNet[0].Network).To(Equal(network.ID))
+					Expect(netip.MustParsePrefix(data.expect.networkIPRange).Contains(netip.MustParseAddr(server.PrivateNet[0].IP))).To(BeTrue())
+				}
+
+				if data.expect.placementGroup == "" {
+					Expect(server.PlacementGroup).To(BeNil())
+				} else {
+					Expect(server.PlacementGroup).NotTo(BeNil())
+					Expect(server.PlacementGroup.Name).To(Equal(data.expect.placementGroup))
+					Expect(server.PlacementGroup.Servers).To(Equal([]int{server.ID}))
+				}
+			},
+
+			Entry("creates servers with the firewalls requested", &data{
+				providerSpec: mock.TestProviderSpecWithFirewalls,
+				expect:       expect{firewall: mock.TestFirewallName},
+			}),
+			Entry("creates and attaches the volumes requested", &data{
+				providerSpec: mock.TestProviderSpecWithVolumes,
+				expect:       expect{volume: fmt.Sprintf("%s-volume-0", fmt.Sprintf(mock.TestServerNameTemplate, 0))},
+			}),
+			Entry("attaches servers to the networks requested", &data{
+				providerSpec: mock.TestProviderSpecWithNetworks,
+				expect:       expect{network: mock.TestNetworkName, networkIPRange: "10.0.0.0/24"},
+			}),
+			Entry("adds servers to the placement group requested", &data{
+				providerSpec: mock.TestProviderSpecWithPlacementGroup,
+				expect:       expect{placementGroup: mock.TestPlacementGroupName},
+			}),
+			Entry("adds servers to an auto-created placement group", &data{
+				providerSpec: strings.Replace(mock.TestProviderSpecWithPlacementGroup, mock.TestPlacementGroupName, "new-placement-group", 1),
+				expect:       expect{placementGroup: "new-placement-group"},
+			}),
+		)
+
+		It("should attach servers to the IP range and alias IPs of a network requested", func() {
+			ctx := context.Background()
+			machine := mock.NewMachine(-1)
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"Networks": []apis.NetworkSpec{{Name: mock.TestNetworkName, IPRange: "10.0.1.0/24", AliasIPs: []string{"10.0.1.100"}}},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			machineClass := mock.NewMachineClassWithProviderSpec(providerSpec)
+
+			createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTestEnv.API.Servers()[0].PrivateNet).To(BeEmpty())
+
+			machine.Spec.ProviderID = createResp.ProviderID
+
+			_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			server := fakeTestEnv.API.Servers()[0]
+
+			requests := fakeTestEnv.API.Requests(http.MethodPost, fmt.Sprintf("/servers/%d/actions/attach_to_network", server.ID))
+			Expect(requests).To(HaveLen(1))
+
+			var body map[string]interface{}
+			Expect(json.Unmarshal(requests[0].Body, &body)).To(Succeed())
+			Expect(body).To(HaveKeyWithValue("ip_range", "10.0.1.0/24"))
+			Expect(body).To(HaveKeyWithValue("alias_ips", []interface{}{"10.0.1.100"}))
+
+			Expect(server.PrivateNet).To(HaveLen(1))
+			Expect(netip.MustParsePrefix("10.0.1.0/24").Contains(netip.MustParseAddr(server.PrivateNet[0].IP))).To(BeTrue())
+			Expect(server.PrivateNet[0].AliasIPs).To(Equal([]string{"10.0.1.100"}))
+		})
+
+		It("should apply missing alias IPs to servers already attached to a network", func() {
+			ctx := context.Background()
+
+			network, _, err := fakeTestEnv.Client.Network.GetByName(ctx, mock.TestNetworkName)
+			Expect(err).NotTo(HaveOccurred())
+
+			serverID := fakeTestEnv.API.AddServer(schema.Server{
+				Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+				Status:     "running",
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+				Labels:     map[string]string{},
+				PrivateNet: []schema.ServerPrivateNet{{Network: network.ID, IP: "10.0.1.2", AliasIPs: []string{}}},
+			})
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"Networks": []apis.NetworkSpec{{Name: mock.TestNetworkName, IPRange: "10.0.1.0/24", AliasIPs: []string{"10.0.1.100", "10.0.1.101"}}},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+				Machine:      mock.NewMachine(serverID),
+				MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeTestEnv.API.Requests(http.MethodPost, fmt.Sprintf("/servers/%d/actions/attach_to_network", serverID))).To(BeEmpty())
+
+			requests := fakeTestEnv.API.Requests(http.MethodPost, fmt.Sprintf("/servers/%d/actions/change_alias_ips", serverID))
+			Expect(requests).To(HaveLen(1))
+
+			var body schema.ServerActionChangeAliasIPsRequest
+			Expect(json.Unmarshal(requests[0].Body, &body)).To(Succeed())
+			Expect(body.Network).To(Equal(network.ID))
+			Expect(body.AliasIPs).To(Equal([]string{"10.0.1.100", "10.0.1.101"}))
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers[0].PrivateNet).To(HaveLen(1))
+			Expect(servers[0].PrivateNet[0].IP).To(Equal("10.0.1.2"))
+			Expect(servers[0].PrivateNet[0].AliasIPs).To(Equal([]string{"10.0.1.100", "10.0.1.101"}))
+		})
+
+		It("should create an auto-created placement group again if it is deleted concurrently", func() {
+			ctx := context.Background()
+
+			placementGroupID := fakeTestEnv.API.AddPlacementGroup(schema.PlacementGroup{
+				Name:   "auto-placement-group",
+				Type:   "spread",
+				Labels: map[string]string{"mcm.gardener.cloud/cluster": mock.TestCluster, "placement.hcloud.mcm.gardener.cloud/auto-created": "true"},
+			})
+
+			// The last server of the placement group is deleted after the placement group has been resolved
+			fakeTestEnv.Faults.Inject(http.MethodPost, "/servers", mock.Fault{Times: 1, Before: func() {
+				_, err := fakeTestEnv.Client.PlacementGroup.Delete(ctx, &hcloud.PlacementGroup{ID: placementGroupID})
+				Expect(err).NotTo(HaveOccurred())
+			}})
+
+			_, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      mock.NewMachine(-1),
+				MachineClass: mock.NewMachineClassWithProviderSpec([]byte(strings.Replace(mock.TestProviderSpecWithPlacementGroup, mock.TestPlacementGroupName, "auto-placement-group", 1))),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers).To(HaveLen(1))
+			Expect(servers[0].PlacementGroup).NotTo(BeNil())
+			Expect(servers[0].PlacementGroup.Name).To(Equal("auto-placement-group"))
+			Expect(servers[0].PlacementGroup.ID).NotTo(Equal(placementGroupID))
+			Expect(fakeTestEnv.API.Requests(http.MethodPost, "/servers")).To(HaveLen(2))
+		})
+	})
+
+	Describe("fault injection", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type setup struct {
+			faults       map[string]mock.Fault
+			actionFaults map[string]mock.ActionFault
+		}
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+			serversLeft       int
+		}
+
+		type data struct {
+			setup  setup
+			expect expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#CreateMachine",
+			func(data *data) {
+				ctx := context.Background()
+
+				for endpoint, fault := range data.setup.faults {
+					method, pattern, _ := strings.Cut(endpoint, " ")
+					fakeTestEnv.Faults.Inject(method, pattern, fault)
+				}
+
+				for command, actionFault := range data.setup.actionFaults {
+					fakeTestEnv.API.ActionFaults[command] = actionFault
+				}
+
+				actionWaitTimeout := apis.ActionWaitTimeout
+				apis.ActionWaitTimeout = 2 * time.Second
+				defer func() { apis.ActionWaitTimeout = actionWaitTimeout }()
+
+				_, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      mock.NewMachine(-1),
+					MachineClass: mock.NewMachineClass(),
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+				} else {
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(fakeTestEnv.API.Servers()).To(HaveLen(data.expect.serversLeft))
+			},
+
+			Entry("succeeds with a slow API", &data{
+				setup: setup{
+					faults: map[string]mock.Fault{"GET /images": {Latency: 50 * time.Millisecond}},
+				},
+				expect: expect{serversLeft: 1},
+			}),
+			Entry("maps a failing server creation to Unavailable", &data{
+				setup: setup{
+					faults: map[string]mock.Fault{"POST /servers": {StatusCode: http.StatusInternalServerError}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable},
+			}),
+			Entry("maps an exhausted rate limit to ResourceExhausted", &data{
+				setup: setup{
+					faults: map[string]mock.Fault{"POST /servers": {StatusCode: http.StatusTooManyRequests, Code: "rate_limit_exceeded"}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.ResourceExhausted},
+			}),
+			Entry("maps an unexpected error body to InvalidArgument", &data{
+				setup: setup{
+					faults: map[string]mock.Fault{"GET /ssh_keys": {StatusCode: http.StatusServiceUnavailable, Body: "{}"}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.InvalidArgument},
+			}),
+			Entry("cleans up a server if its creation action failed", &data{
+				setup: setup{
+					actionFaults: map[string]mock.ActionFault{"create_server": {Code: "server_error", Message: "simulated failure"}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unknown},
+			}),
+			Entry("cleans up a server if its creation action is stuck", &data{
+				setup: setup{
+					actionFaults: map[string]mock.ActionFault{"create_server": {Stuck: true}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unknown},
+			}),
+			Entry("cleans up a server if polling its creation action fails", &data{
+				setup: setup{
+					faults: map[string]mock.Fault{"GET /actions/*": {StatusCode: http.StatusBadGateway, Times: 1}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable},
+			}),
+		)
+
+		It("should report a failing power-on as Unavailable", func() {
+			ctx := context.Background()
+			machine := mock.NewMachine(-1)
+
+			createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      machine,
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			machine.Spec.ProviderID = createResp.ProviderID
+			fakeTestEnv.Faults.Inject(http.MethodPost, "/servers/*/actions/poweron", mock.Fault{StatusCode: http.StatusInternalServerError})
+
+			_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+				Machine:      machine,
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).To(HaveOccurred())
+
+			errStatus, ok := err.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(codes.Unavailable))
+			Expect(fakeTestEnv.API.Servers()[0].Status).To(Equal("off"))
+		})
+	})
+
+	Describe("server type fallbacks", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type setup struct {
+			unavailableServerTypes map[string][]string
+		}
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+			providerID        string
+			serverType        string
+			zone              string
+		}
+
+		type data struct {
+			setup  setup
+			expect expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#CreateMachine",
+			func(data *data) {
+				ctx := context.Background()
+				machineClass := mock.NewMachineClassWithProviderSpec([]byte(mock.TestProviderSpecWithFallbacks))
+
+				for datacenter, serverTypes := range data.setup.unavailableServerTypes {
+					fakeTestEnv.API.UnavailableServerTypes[datacenter] = serverTypes
+				}
+
+				resp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      mock.NewMachine(-1),
+					MachineClass: machineClass,
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+					Expect(fakeTestEnv.API.Servers()).To(BeEmpty())
+
+					return
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.ProviderID).To(HavePrefix(data.expect.providerID))
+
+				servers := fakeTestEnv.API.Servers()
+				Expect(servers).To(HaveLen(1))
+				Expect(servers[0].ServerType.Name).To(Equal(data.expect.serverType))
+				Expect(servers[0].Datacenter.Name).To(Equal(data.expect.zone))
+				Expect(servers[0].Labels).To(HaveKeyWithValue("node.kubernetes.io/instance-type", data.expect.serverType))
+				Expect(servers[0].Labels).To(HaveKeyWithValue("topology.kubernetes.io/zone", data.expect.zone))
+
+				listResp, err := provider.ListMachines(ctx, &driver.ListMachinesRequest{
+					MachineClass: machineClass,
+					Secret:       providerSecret,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(listResp.MachineList).To(HaveKey(resp.ProviderID))
+			},
+
+			Entry("uses the preferred server type and zone", &data{
+				expect: expect{providerID: "hcloud:///hel1-dc2/", serverType: "cx11-ceph", zone: "hel1-dc2"},
+			}),
+			Entry("falls back to another zone", &data{
+				setup: setup{
+					unavailableServerTypes: map[string][]string{"hel1-dc2": {"cx11-ceph"}},
+				},
+				expect: expect{providerID: "hcloud:///hel1-dc3/", serverType: "cx11-ceph", zone: "hel1-dc3"},
+			}),
+			Entry("falls back to another server type", &data{
+				setup: setup{
+					unavailableServerTypes: map[string][]string{"hel1-dc2": {"cx11-ceph"}, "hel1-dc3": {"cx11-ceph"}},
+				},
+				expect: expect{providerID: "hcloud:///hel1-dc2/", serverType: "cx21", zone: "hel1-dc2"},
+			}),
+			Entry("reports ResourceExhausted if no server type is available", &data{
+				setup: setup{
+					unavailableServerTypes: map[string][]string{"hel1-dc2": {"cx11-ceph", "cx21"}, "hel1-dc3": {"cx11-ceph", "cx21"}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.ResourceExhausted},
+			}),
+		)
+	})
+
+	Describe("pre-flight checks", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type setup struct {
+			spec                map[string]interface{}
+			existingServerCores []int
+		}
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+			errMessage        string
+			serverType        string
+			zone              string
+		}
+
+		type data struct {
+			setup  setup
+			expect expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			location := schema.Location{Name: "hel1", NetworkZone: "eu-central"}
+			largeServerTypeID := fakeTestEnv.API.AddServerType(schema.ServerType{
+				Name:   "cx31",
+				Cores:  4,
+				Prices: []schema.PricingServerTypePrice{{Location: "fsn1"}},
+			})
+
+			datacenter := schema.Datacenter{Name: "hel1-dc5", Location: location}
+			datacenter.ServerTypes.Supported = []int{largeServerTypeID}
+			datacenter.ServerTypes.Available = []int{}
+			fakeTestEnv.API.AddDatacenter(datacenter)
+
+			datacenter = schema.Datacenter{Name: "hel1-dc6", Location: location}
+			datacenter.ServerTypes.Supported = []int{largeServerTypeID}
+			datacenter.ServerTypes.Available = []int{largeServerTypeID}
+			fakeTestEnv.API.AddDatacenter(datacenter)
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#CreateMachine",
+			func(data *data) {
+				ctx := context.Background()
+
+				for index, cores := range data.setup.existingServerCores {
+					fakeTestEnv.API.AddServer(schema.Server{
+						Name:       fmt.Sprintf("existing-%d", index),
+						ServerType: schema.ServerType{Name: "existing", Cores: cores},
+					})
+				}
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), data.setup.spec))
+				Expect(err).NotTo(HaveOccurred())
+
+				if data.expect.errToHaveOccurred {
+					// Pre-flight checks must fail before any other lookup
+					fakeTestEnv.Faults.Inject(http.MethodGet, "/images", mock.Fault{StatusCode: http.StatusInternalServerError})
+				}
+
+				_, err = provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      mock.NewMachine(-1),
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+					Expect(errStatus.Message()).To(ContainSubstring(data.expect.errMessage))
+
+					return
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				servers := fakeTestEnv.API.Servers()
+				Expect(servers[len(servers)-1].ServerType.Name).To(Equal(data.expect.serverType))
+				Expect(servers[len(servers)-1].Datacenter.Name).To(Equal(data.expect.zone))
+			},
+
+			Entry("passes all checks", &data{
+				setup: setup{
+					spec:                map[string]interface{}{"Preflight": &apis.PreflightSpec{ServerType: true, MaxServers: 2, MaxCores: 2}},
+					existingServerCores: []int{1},
+				},
+				expect: expect{serverType: mock.TestServerType, zone: mock.TestZone},
+			}),
+			Entry("fails if the project server limit has been reached", &data{
+				setup: setup{
+					spec:                map[string]interface{}{"Preflight": &apis.PreflightSpec{MaxServers: 2}},
+					existingServerCores: []int{1, 1},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.ResourceExhausted, errMessage: "Project server limit of 2 reached"},
+			}),
+			Entry("fails if the project core limit would be exceeded", &data{
+				setup: setup{
+					spec:                map[string]interface{}{"Preflight": &apis.PreflightSpec{MaxCores: 2}},
+					existingServerCores: []int{2},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.ResourceExhausted, errMessage: "server type cx11-ceph needs 1 cores but only 0 of the project core limit of 2 are left"},
+			}),
+			Entry("skips server types exceeding the project core limit", &data{
+				setup: setup{
+					spec: map[string]interface{}{
+						"ServerType":  "cx21",
+						"ServerTypes": []string{mock.TestServerType},
+						"Preflight":   &apis.PreflightSpec{MaxCores: 4},
+					},
+					existingServerCores: []int{1, 2},
+				},
+				expect: expect{serverType: mock.TestServerType, zone: mock.TestZone},
+			}),
+			Entry("skips zones without the server type available", &data{
+				setup: setup{
+					spec: map[string]interface{}{
+						"Zone":          "hel1-dc5",
+						"FallbackZones": []string{"hel1-dc6"},
+						"ServerType":    "cx31",
+						"Preflight":     &apis.PreflightSpec{ServerType: true},
+					},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.ResourceExhausted, errMessage: "server type cx31 is not available in hel1-dc5; server type cx31 has no pricing for location hel1"},
+			}),
+			Entry("falls back to zones with the server type available", &data{
+				setup: setup{
+					spec: map[string]interface{}{
+						"Zone":          "hel1-dc5",
+						"FallbackZones": []string{mock.TestZone},
+						"Preflight":     &apis.PreflightSpec{ServerType: true},
+					},
+				},
+				expect: expect{serverType: mock.TestServerType, zone: mock.TestZone},
+			}),
+			Entry("fails for unknown server types", &data{
+				setup: setup{
+					spec: map[string]interface{}{
+						"ServerType": "unknown",
+						"Preflight":  &apis.PreflightSpec{ServerType: true},
+					},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.InvalidArgument, errMessage: "Server type unknown not found"},
+			}),
+		)
+	})
+
+	Describe("image selection", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+			imageID           int
+		}
+
+		type data struct {
+			serverType string
+			imageSpec  *apis.ImageSpec
+			expect     expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			imageName := "ubuntu-22.04"
+			gardenLinuxLabels := map[string]string{"os": "gardenlinux"}
+
+			fakeTestEnv.API.AddImage(schema.Image{ID: 101, Name: &imageName, Type: "system", Architecture: "x86", Created: time.Date(2022, 4, 21, 0, 0, 0, 0, time.UTC), Deprecated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+			fakeTestEnv.API.AddImage(schema.Image{ID: 102, Name: &imageName, Type: "system", Architecture: "x86", Created: time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC)})
+			fakeTestEnv.API.AddImage(schema.Image{ID: 103, Name: &imageName, Type: "system", Architecture: "arm", Created: time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC)})
+			fakeTestEnv.API.AddImage(schema.Image{ID: 201, Type: "snapshot", Architecture: "x86", Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Labels: gardenLinuxLabels})
+			fakeTestEnv.API.AddImage(schema.Image{ID: 202, Type: "snapshot", Architecture: "x86", Created: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Labels: gardenLinuxLabels})
+			fakeTestEnv.API.AddImage(schema.Image{ID: 203, Type: "snapshot", Architecture: "arm", Created: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), Labels: gardenLinuxLabels})
+			fakeTestEnv.API.AddImage(schema.Image{ID: 204, Type: "snapshot", Architecture: "x86", Created: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), Status: "creating", Labels: gardenLinuxLabels})
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#CreateMachine",
+			func(data *data) {
+				ctx := context.Background()
+
+				serverType := data.serverType
+				if serverType == "" {
+					serverType = mock.TestServerType
+				}
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+					"ServerType": serverType,
+					"ImageName":  "",
+					"Image":      data.imageSpec,
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      mock.NewMachine(-1),
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+
+					return
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				servers := fakeTestEnv.API.Servers()
+				Expect(servers).To(HaveLen(1))
+				Expect(servers[0].Image.ID).To(Equal(data.expect.imageID))
+			},
+
+			Entry("selects an image by ID", &data{
+				imageSpec: &apis.ImageSpec{ID: 201},
+				expect:    expect{imageID: 201},
+			}),
+			Entry("rejects an image ID of another architecture than the server type", &data{
+				imageSpec: &apis.ImageSpec{ID: 203},
+				expect:    expect{errToHaveOccurred: true, errStatus: codes.InvalidArgument},
+			}),
+			Entry("rejects an architecture inconsistent with the server type", &data{
+				imageSpec: &apis.ImageSpec{Name: "ubuntu-22.04", Architecture: "arm"},
+				expect:    expect{errToHaveOccurred: true, errStatus: codes.InvalidArgument},
+			}),
+			Entry("prefers images by name which are not deprecated", &data{
+				imageSpec: &apis.ImageSpec{Name: "ubuntu-22.04", Architecture: "x86"},
+				expect:    expect{imageID: 102},
+			}),
+			Entry("selects an image by name for the architecture of the server type", &data{
+				serverType: mock.TestArmServerType,
+				imageSpec:  &apis.ImageSpec{Name: "ubuntu-22.04"},
+				expect:     expect{imageID: 103},
+			}),
+			Entry("selects an image by ID for ARM server types", &data{
+				serverType: mock.TestArmServerType,
+				imageSpec:  &apis.ImageSpec{ID: 203, Architecture: "arm"},
+				expect:     expect{imageID: 203},
+			}),
+			Entry("selects the newest available snapshot by label selector", &data{
+				imageSpec: &apis.ImageSpec{LabelSelector: "os=gardenlinux"},
+				expect:    expect{imageID: 202},
+			}),
+			Entry("selects the newest available snapshot by label selector for ARM server types", &data{
+				serverType: mock.TestArmServerType,
+				imageSpec:  &apis.ImageSpec{LabelSelector: "os=gardenlinux"},
+				expect:     expect{imageID: 203},
+			}),
+			Entry("fails if no snapshot matches the label selector", &data{
+				imageSpec: &apis.ImageSpec{LabelSelector: "os=unknown"},
+				expect:    expect{errToHaveOccurred: true, errStatus: codes.InvalidArgument},
+			}),
+		)
+
+		It("should resolve the image for the architecture of a fallback server type", func() {
+			ctx := context.Background()
+
+			fakeTestEnv.API.UnavailableServerTypes = map[string][]string{mock.TestZone: {mock.TestServerType}}
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"ServerTypes": []string{mock.TestArmServerType},
+				"ImageName":   "",
+				"Image":       &apis.ImageSpec{Name: "ubuntu-22.04"},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      mock.NewMachine(-1),
+				MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers).To(HaveLen(1))
+			Expect(servers[0].ServerType.Name).To(Equal(mock.TestArmServerType))
+			Expect(servers[0].Image.ID).To(Equal(103))
+		})
+
+		It("should skip server types of another architecture than the image requested", func() {
+			ctx := context.Background()
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"ServerType":  mock.TestArmServerType,
+				"ServerTypes": []string{mock.TestServerType},
+				"ImageName":   "",
+				"Image":       &apis.ImageSpec{Name: "ubuntu-22.04", Architecture: "x86"},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      mock.NewMachine(-1),
+				MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers).To(HaveLen(1))
+			Expect(servers[0].ServerType.Name).To(Equal(mock.TestServerType))
+			Expect(servers[0].Image.ID).To(Equal(102))
+		})
+	})
+
+	Describe("graceful shutdown", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type setup struct {
+			serverStatus     string
+			shutdownDuration time.Duration
+		}
+
+		type expect struct {
+			commands []string
+		}
+
+		type data struct {
+			setup    setup
+			shutdown *apis.ShutdownSpec
+			expect   expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#DeleteMachine",
+			func(data *data) {
+				ctx := context.Background()
+
+				fakeTestEnv.API.ShutdownDuration = data.setup.shutdownDuration
+
+				serverID := fakeTestEnv.API.AddServer(schema.Server{
+					Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+					Status:     data.setup.serverStatus,
+					Datacenter: schema.Datacenter{Name: mock.TestZone},
+					Labels:     map[string]string{},
+				})
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+					"Shutdown": data.shutdown,
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+					Machine:      mock.NewMachine(serverID),
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeTestEnv.API.Servers()).To(BeEmpty())
+
+				commands := []string{}
+				for _, action := range fakeTestEnv.API.Actions() {
+					commands = append(commands, action.Command)
+				}
+
+				Expect(commands).To(Equal(data.expect.commands))
+			},
+
+			Entry("deletes running servers immediately by default", &data{
+				setup:  setup{serverStatus: "running"},
+				expect: expect{commands: []string{"delete_server"}},
+			}),
+			Entry("shuts running servers down gracefully if requested", &data{
+				setup:    setup{serverStatus: "running", shutdownDuration: 100 * time.Millisecond},
+				shutdown: &apis.ShutdownSpec{Timeout: "5s"},
+				expect:   expect{commands: []string{"shutdown_server", "delete_server"}},
+			}),
+			Entry("powers servers off not shutting down within the timeout", &data{
+				setup:    setup{serverStatus: "running", shutdownDuration: -1},
+				shutdown: &apis.ShutdownSpec{Timeout: "1s"},
+				expect:   expect{commands: []string{"shutdown_server", "poweroff_server", "delete_server"}},
+			}),
+			Entry("deletes servers already off immediately", &data{
+				setup:    setup{serverStatus: "off"},
+				shutdown: &apis.ShutdownSpec{},
+				expect:   expect{commands: []string{"delete_server"}},
+			}),
+		)
+	})
+
+	Describe("server deletion", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type setup struct {
+			serverGone   bool
+			actionFaults map[string]mock.ActionFault
+		}
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+			serversLeft       int
+			resourcesLeft     int
+		}
+
+		type data struct {
+			setup  setup
+			expect expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#DeleteMachine",
+			func(data *data) {
+				ctx := context.Background()
+
+				for command, actionFault := range data.setup.actionFaults {
+					fakeTestEnv.API.ActionFaults[command] = actionFault
+				}
+
+				machine := mock.NewMachine(-1)
+				var serverRef *int
+
+				if !data.setup.serverGone {
+					serverID := fakeTestEnv.API.AddServer(schema.Server{
+						Name:       machine.Name,
+						Status:     "off",
+						Datacenter: schema.Datacenter{Name: mock.TestZone},
+						Labels:     map[string]string{},
+					})
+
+					serverRef = &serverID
+				}
+
+				fakeTestEnv.API.AddFloatingIP(schema.FloatingIP{
+					Name:   fmt.Sprintf("pool-%s-ipv4", machine.Name),
+					Type:   "ipv4",
+					Server: serverRef,
+					Labels: map[string]string{},
+				})
+
+				fakeTestEnv.API.AddVolume(schema.Volume{
+					Name:   fmt.Sprintf("%s-volume-0", machine.Name),
+					Size:   10,
+					Server: serverRef,
+					Labels: map[string]string{},
+				})
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+					"FloatingPoolName": "pool",
+					"Volumes":          []apis.VolumeSpec{{Size: 10}},
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				actionWaitTimeout := apis.ActionWaitTimeout
+				apis.ActionWaitTimeout = 2 * time.Second
+				defer func() { apis.ActionWaitTimeout = actionWaitTimeout }()
+
+				_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+					Machine:      machine,
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+				} else {
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(fakeTestEnv.API.Servers()).To(HaveLen(data.expect.serversLeft))
+				Expect(fakeTestEnv.API.FloatingIPs()).To(HaveLen(data.expect.resourcesLeft))
+				Expect(fakeTestEnv.API.Volumes()).To(HaveLen(data.expect.resourcesLeft))
+			},
+
+			Entry("releases the resources of a machine once its server is deleted", &data{
+				expect: expect{},
+			}),
+			Entry("keeps the resources of a machine if its server deletion failed", &data{
+				setup: setup{
+					actionFaults: map[string]mock.ActionFault{"delete_server": {Code: "server_error", Message: "simulated failure"}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable, serversLeft: 1, resourcesLeft: 1},
+			}),
+			Entry("keeps the resources of a machine if its server deletion is stuck", &data{
+				setup: setup{
+					actionFaults: map[string]mock.ActionFault{"delete_server": {Stuck: true}},
+				},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable, serversLeft: 1, resourcesLeft: 1},
+			}),
+			Entry("releases leftover resources of a machine if its server is already gone", &data{
+				setup:  setup{serverGone: true},
+				expect: expect{},
+			}),
+		)
+	})
+
+	Describe("server protection", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		It("should protect servers and lift the protection before deleting them", func() {
+			ctx := context.Background()
+			machine := mock.NewMachine(-1)
+
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+				"Protection": &apis.ProtectionSpec{Delete: true, Rebuild: true},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			machineClass := mock.NewMachineClassWithProviderSpec(providerSpec)
+
+			createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			machine.Spec.ProviderID = createResp.ProviderID
+
+			_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers).To(HaveLen(1))
+			Expect(servers[0].Protection).To(Equal(schema.ServerProtection{Delete: true, Rebuild: true}))
+
+			// Deletions outside of MCM are rejected
+			server, _, err := fakeTestEnv.Client.Server.GetByID(ctx, servers[0].ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = fakeTestEnv.Client.Server.DeleteWithResult(ctx, server)
+			Expect(hcloud.IsError(err, hcloud.ErrorCodeProtected)).To(BeTrue())
+
+			_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+				Machine:      machine,
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTestEnv.API.Servers()).To(BeEmpty())
+
+			commands := []string{}
+			for _, action := range fakeTestEnv.API.Actions() {
+				if action.Command != "create_server" && action.Command != "start_server" {
+					commands = append(commands, action.Command)
+				}
+			}
+
+			Expect(commands).To(Equal([]string{"change_protection", "change_protection", "delete_server"}))
+		})
+
+		It("should keep protected servers if lifting the protection fails", func() {
+			ctx := context.Background()
+
+			serverID := fakeTestEnv.API.AddServer(schema.Server{
+				Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+				Status:     "off",
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+				Labels:     map[string]string{},
+				Protection: schema.ServerProtection{Delete: true, Rebuild: true},
+			})
+
+			fakeTestEnv.Faults.Inject(http.MethodPost, "/servers/*/actions/change_protection", mock.Fault{StatusCode: http.StatusInternalServerError})
+
+			_, err := provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+				Machine:      mock.NewMachine(serverID),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).To(HaveOccurred())
+
+			errStatus, ok := err.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(codes.Unavailable))
+			Expect(fakeTestEnv.API.Servers()).To(HaveLen(1))
+		})
+	})
+
+	Describe("server health", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type setup struct {
+			serverStatus  string
+			locked        bool
+			machinePhase  v1alpha1.MachinePhase
+			pendingAction bool
+		}
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+			errMessage        string
+		}
+
+		type data struct {
+			setup       setup
+			autoPowerOn bool
+			expect      expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#GetMachineStatus",
+			func(data *data) {
+				ctx := context.Background()
+
+				serverID := fakeTestEnv.API.AddServer(schema.Server{
+					Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+					Status:     data.setup.serverStatus,
+					Locked:     data.setup.locked,
+					Datacenter: schema.Datacenter{Name: mock.TestZone},
+					Labels:     map[string]string{},
+				})
+
+				if data.setup.pendingAction {
+					fakeTestEnv.API.ActionDuration = time.Hour
+
+					_, _, err := fakeTestEnv.Client.Server.Poweron(ctx, &hcloud.Server{ID: serverID})
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				machine := mock.NewMachine(serverID)
+				machine.Status.CurrentStatus.Phase = data.setup.machinePhase
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+					"AutoPowerOn": data.autoPowerOn,
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				actionsBefore := len(fakeTestEnv.API.Actions())
+				statusBefore := fakeTestEnv.API.Servers()[0].Status
+
+				_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+					Machine:      machine,
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(data.expect.errMessage))
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+				} else {
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				// The status of machines is reported without changing the server
+				Expect(fakeTestEnv.API.Actions()).To(HaveLen(actionsBefore))
+				Expect(fakeTestEnv.API.Servers()[0].Status).To(Equal(statusBefore))
+			},
+
+			Entry("reports running servers as healthy", &data{
+				setup:  setup{serverStatus: "running", machinePhase: v1alpha1.MachineRunning},
+				expect: expect{},
+			}),
+			Entry("reports servers not yet initialized as Uninitialized", &data{
+				setup:  setup{serverStatus: "off"},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Uninitialized, errMessage: "Server is off instead of running"},
+			}),
+			Entry("reports servers of machines crashing during creation as Uninitialized", &data{
+				setup:  setup{serverStatus: "off", machinePhase: v1alpha1.MachineCrashLoopBackOff},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Uninitialized, errMessage: "Server is off instead of running"},
+			}),
+			Entry("reports servers stopped unexpectedly as Unavailable", &data{
+				setup:  setup{serverStatus: "off", machinePhase: v1alpha1.MachineRunning},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable, errMessage: "is off unexpectedly"},
+			}),
+			Entry("reports servers stopped unexpectedly as Uninitialized to power them on if requested", &data{
+				setup:       setup{serverStatus: "off", machinePhase: v1alpha1.MachineUnknown},
+				autoPowerOn: true,
+				expect:      expect{errToHaveOccurred: true, errStatus: codes.Uninitialized, errMessage: "Server is off instead of running"},
+			}),
+			Entry("reports starting servers as Uninitialized", &data{
+				setup:  setup{serverStatus: "starting"},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Uninitialized, errMessage: "Server is starting instead of running"},
+			}),
+			Entry("reports initializing servers as Uninitialized", &data{
+				setup:  setup{serverStatus: "initializing"},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Uninitialized, errMessage: "Server is initializing instead of running"},
+			}),
+			Entry("reports stopping servers as Uninitialized", &data{
+				setup:  setup{serverStatus: "stopping"},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Uninitialized, errMessage: "Server is stopping instead of running"},
+			}),
+			Entry("reports migrating servers as Unavailable", &data{
+				setup:  setup{serverStatus: "migrating", machinePhase: v1alpha1.MachineRunning},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable, errMessage: "is migrating"},
+			}),
+			Entry("reports servers in an unknown state as Unavailable", &data{
+				setup:  setup{serverStatus: "unknown", machinePhase: v1alpha1.MachineRunning},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable, errMessage: "is unknown"},
+			}),
+			Entry("reports locked servers as Unavailable", &data{
+				setup:  setup{serverStatus: "running", locked: true, machinePhase: v1alpha1.MachineRunning},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Unavailable, errMessage: "is locked"},
+			}),
+			Entry("reports servers with running actions as Uninitialized", &data{
+				setup:  setup{serverStatus: "off", pendingAction: true},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.Uninitialized, errMessage: "has 1 running actions"},
+			}),
+		)
+
+		It("should report stuck actions of servers as Unavailable", func() {
+			ctx := context.Background()
+
+			fakeTestEnv.API.ActionFaults["start_server"] = mock.ActionFault{Stuck: true}
+
+			serverID := fakeTestEnv.API.AddServer(schema.Server{
+				Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+				Status:     "off",
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+				Labels:     map[string]string{},
+			})
+
+			_, _, err := fakeTestEnv.Client.Server.Poweron(ctx, &hcloud.Server{ID: serverID})
+			Expect(err).NotTo(HaveOccurred())
+
+			actionWaitTimeout := apis.ActionWaitTimeout
+			apis.ActionWaitTimeout = 0
+			defer func() { apis.ActionWaitTimeout = actionWaitTimeout }()
+
+			_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      mock.NewMachine(serverID),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Action start_server"))
+			Expect(err.Error()).To(ContainSubstring("is stuck"))
+
+			errStatus, ok := err.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(codes.Unavailable))
+		})
+
+		It("should not report servers as not found if their actions are not found", func() {
+			ctx := context.Background()
+
+			serverID := fakeTestEnv.API.AddServer(schema.Server{
+				Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+				Status:     "running",
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+				Labels:     map[string]string{},
+			})
+
+			fakeTestEnv.Faults.Inject(http.MethodGet, fmt.Sprintf("/servers/%d/actions", serverID), mock.Fault{
+				StatusCode: http.StatusNotFound,
+				Code:       string(hcloud.ErrorCodeNotFound),
+				Message:    "action not found",
+			})
+
+			_, err := provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      mock.NewMachine(serverID),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).To(HaveOccurred())
+
+			errStatus, ok := err.(*status.Status)
+			Expect(ok).To(BeTrue())
+			Expect(errStatus.Code()).To(Equal(codes.Unavailable))
+		})
+
+		It("should only power servers of running machines on again if requested", func() {
+			ctx := context.Background()
+
+			for _, autoPowerOn := range []bool{false, true} {
+				serverID := fakeTestEnv.API.AddServer(schema.Server{
+					Name:       fmt.Sprintf(mock.TestServerNameTemplate, 0),
+					Status:     "off",
+					Datacenter: schema.Datacenter{Name: mock.TestZone},
+					Labels:     map[string]string{},
+				})
+
+				machine := mock.NewMachine(serverID)
+				machine.Status.CurrentStatus.Phase = v1alpha1.MachineRunning
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+					"AutoPowerOn": autoPowerOn,
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = provider.InitializeMachine(ctx, &driver.InitializeMachineRequest{
+					Machine:      machine,
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+
+				server, _, getErr := fakeTestEnv.Client.Server.GetByID(ctx, serverID)
+				Expect(getErr).NotTo(HaveOccurred())
+
+				if autoPowerOn {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
+				} else {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(codes.Unavailable))
+					Expect(server.Status).To(Equal(hcloud.ServerStatusOff))
+				}
+
+				_, err = fakeTestEnv.Client.Server.Delete(ctx, server)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should let the machine controller manager initialize servers powered off without failing the machine", func() {
+			ctx := context.Background()
+
+			// Create the server and power it off before the machine controller manager picks the machine up
+			createResp, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      mock.NewMachine(-1),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			serverID, err := transcoder.DecodeServerIDFromProviderID(createResp.ProviderID)
+			Expect(err).NotTo(HaveOccurred())
+
+			action, _, err := fakeTestEnv.Client.Server.Poweroff(ctx, &hcloud.Server{ID: serverID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTestEnv.Client.Action.WaitFor(ctx, action)).To(Succeed())
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: mock.TestNamespace},
+				Data:       providerSecret.Data,
+			}
+
+			machineClass := mock.NewMachineClass()
+			machineClass.ObjectMeta = metav1.ObjectMeta{
+				Name:       "machine-class",
+				Namespace:  mock.TestNamespace,
+				Finalizers: []string{machinecontroller.MCMFinalizerName},
+			}
+			machineClass.SecretRef = &corev1.SecretReference{Name: secret.Name, Namespace: secret.Namespace}
+
+			machine := mock.NewMachine(-1)
+			machine.Spec.Class = v1alpha1.ClassSpec{Kind: "MachineClass", Name: machineClass.Name}
+
+			machineClient := mcmfake.NewSimpleClientset(machineClass, machine)
+			controlCoreClient := kubefake.NewSimpleClientset(secret)
+			targetCoreClient := kubefake.NewSimpleClientset()
+
+			machineInformers := mcminformers.NewSharedInformerFactoryWithOptions(machineClient, 0, mcminformers.WithNamespace(mock.TestNamespace))
+			controlCoreInformers := kubeinformers.NewSharedInformerFactoryWithOptions(controlCoreClient, 0, kubeinformers.WithNamespace(mock.TestNamespace))
+			targetCoreInformers := kubeinformers.NewSharedInformerFactory(targetCoreClient, 0)
+
+			controller, err := machinecontroller.NewController(
+				mock.TestNamespace,
+				machineClient.MachineV1alpha1(),
+				controlCoreClient,
+				targetCoreClient,
+				provider,
+				targetCoreInformers.Core().V1().PersistentVolumeClaims(),
+				targetCoreInformers.Core().V1().PersistentVolumes(),
+				controlCoreInformers.Core().V1().Secrets(),
+				targetCoreInformers.Core().V1().Nodes(),
+				targetCoreInformers.Core().V1().Pods(),
+				targetCoreInformers.Policy().V1().PodDisruptionBudgets(),
+				targetCoreInformers.Storage().V1().VolumeAttachments(),
+				machineInformers.Machine().V1alpha1().MachineClasses(),
+				machineInformers.Machine().V1alpha1().Machines(),
+				record.NewFakeRecorder(100),
+				options.SafetyOptions{
+					MachineCreationTimeout:                   metav1.Duration{Duration: 20 * time.Minute},
+					MachineHealthTimeout:                     metav1.Duration{Duration: 10 * time.Minute},
+					MachineDrainTimeout:                      metav1.Duration{Duration: 10 * time.Minute},
+					MachineSafetyOrphanVMsPeriod:             metav1.Duration{Duration: time.Hour},
+					MachineSafetyAPIServerStatusCheckPeriod:  metav1.Duration{Duration: time.Hour},
+					MachineSafetyAPIServerStatusCheckTimeout: metav1.Duration{Duration: time.Hour},
+				},
+				"",
+				"",
+				semver.MustParse("1.32.0"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			machineInformers.Start(stopCh)
+			controlCoreInformers.Start(stopCh)
+			targetCoreInformers.Start(stopCh)
+
+			go controller.Run(1, stopCh)
+
+			Eventually(func() (v1alpha1.MachinePhase, error) {
+				machine, err := machineClient.MachineV1alpha1().Machines(mock.TestNamespace).Get(ctx, machine.Name, metav1.GetOptions{})
+				if err != nil {
+					return "", err
+				}
+
+				return machine.Status.CurrentStatus.Phase, nil
+			}, 30*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.MachinePending))
+
+			servers := fakeTestEnv.API.Servers()
+			Expect(servers).To(HaveLen(1))
+			Expect(servers[0].Status).To(Equal("running"))
+
+			// The machine controller manager maps the codes returned to phases it must never have set in between
+			for _, action := range machineClient.Actions() {
+				update, ok := action.(k8stesting.UpdateAction)
+				if !ok {
+					continue
+				}
+
+				updatedMachine, ok := update.GetObject().(*v1alpha1.Machine)
+				if !ok {
+					continue
+				}
+
+				Expect(updatedMachine.Status.CurrentStatus.Phase).NotTo(BeElementOf(v1alpha1.MachineCrashLoopBackOff, v1alpha1.MachineFailed))
+				Expect(updatedMachine.Status.LastOperation.State).NotTo(Equal(v1alpha1.MachineStateFailed))
+			}
+		})
+	})
+
+	Describe("metadata labels", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type setup struct {
+			ownerName          string
+			machineLabels      map[string]string
+			nodeTemplateLabels map[string]string
+		}
+
+		type data struct {
+			setup  setup
+			spec   map[string]interface{}
+			expect map[string]string
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#CreateMachine",
+			func(data *data) {
+				ctx := context.Background()
+
+				machine := mock.NewMachine(-1)
+				machine.Labels = data.setup.machineLabels
+				machine.Spec.NodeTemplateSpec.Labels = data.setup.nodeTemplateLabels
+
+				if data.setup.ownerName != "" {
+					machine.OwnerReferences = []metav1.OwnerReference{{APIVersion: "machine.sapcloud.io/v1alpha1", Kind: "MachineSet", Name: data.setup.ownerName}}
+				}
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), data.spec))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      machine,
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				servers := fakeTestEnv.API.Servers()
+				Expect(servers).To(HaveLen(1))
+
+				for key, value := range data.expect {
+					if value == "" {
+						Expect(servers[0].Labels).NotTo(HaveKey(key))
+					} else {
+						Expect(servers[0].Labels).To(HaveKeyWithValue(key, value))
+					}
+				}
+			},
+
+			Entry("adds the namespace of standalone machines", &data{
+				spec: map[string]interface{}{},
+				expect: map[string]string{
+					"mcm.gardener.cloud/namespace":          mock.TestNamespace,
+					"mcm.gardener.cloud/machine-set":        "",
+					"mcm.gardener.cloud/machine-deployment": "",
+				},
+			}),
+			Entry("adds the MachineSet and MachineDeployment owning the machine", &data{
+				setup: setup{
+					ownerName:     "workers-z1-7fb8c",
+					machineLabels: map[string]string{"machine-template-hash": "3619417183"},
+				},
+				spec: map[string]interface{}{},
+				expect: map[string]string{
+					"mcm.gardener.cloud/namespace":          mock.TestNamespace,
+					"mcm.gardener.cloud/machine-set":        "workers-z1-7fb8c",
+					"mcm.gardener.cloud/machine-deployment": "workers-z1",
+				},
+			}),
+			Entry("adds the MachineSet of machines not owned by a MachineDeployment", &data{
+				setup:  setup{ownerName: "workers"},
+				spec:   map[string]interface{}{},
+				expect: map[string]string{"mcm.gardener.cloud/machine-set": "workers", "mcm.gardener.cloud/machine-deployment": ""},
+			}),
+			Entry("adds the node template labels requested", &data{
+				setup: setup{
+					nodeTemplateLabels: map[string]string{"worker.gardener.cloud/pool": "workers", "team": "b", "environment": "production"},
+				},
+				spec: map[string]interface{}{
+					"Labels":             map[string]string{"team": "a", "cost-center": "42"},
+					"NodeTemplateLabels": []string{"worker.gardener.cloud/pool", "team", "missing"},
+				},
+				expect: map[string]string{
+					"worker.gardener.cloud/pool": "workers",
+					"team":                       "b",
+					"cost-center":                "42",
+					"environment":                "",
+					"missing":                    "",
+				},
+			}),
+		)
+	})
+
+	Describe("SSH keys", func() {
+		var fakeTestEnv mock.MockTestEnv
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+			sshKeyIDs         []int
+		}
+
+		type data struct {
+			spec   map[string]interface{}
+			expect expect
+		}
+
+		var _ = BeforeEach(func() {
+			fakeTestEnv = mock.NewFakeTestEnv()
+			fakeTestEnv.API.ActionDuration = 10 * time.Millisecond
+
+			fakeTestEnv.API.AddSSHKey(schema.SSHKey{ID: 301, Name: "team-a-1", Fingerprint: "01:01", Labels: map[string]string{"team": "a"}})
+			fakeTestEnv.API.AddSSHKey(schema.SSHKey{ID: 302, Name: "team-a-2", Fingerprint: "02:02", Labels: map[string]string{"team": "a"}})
+			fakeTestEnv.API.AddSSHKey(schema.SSHKey{ID: 303, Name: "team-b-1", Fingerprint: "03:03", Labels: map[string]string{"team": "b"}})
+
+			apis.SetClientForToken("dummy-token", fakeTestEnv.Client)
+		})
+
+		var _ = AfterEach(func() {
+			fakeTestEnv.Teardown()
+		})
+
+		DescribeTable("#CreateMachine",
+			func(data *data) {
+				ctx := context.Background()
+
+				spec := map[string]interface{}{"SSHFingerprint": ""}
+				for key, value := range data.spec {
+					spec[key] = value
+				}
+
+				providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), spec))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      mock.NewMachine(-1),
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+
+					Expect(fakeTestEnv.API.Servers()).To(BeEmpty())
+
+					return
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				servers := fakeTestEnv.API.Servers()
+				Expect(servers).To(HaveLen(1))
+
+				sshKeyIDs := fakeTestEnv.API.ServerSSHKeys(servers[0].ID)
+				if len(data.expect.sshKeyIDs) == 0 {
+					Expect(sshKeyIDs).To(BeEmpty())
+				} else {
+					Expect(sshKeyIDs).To(Equal(data.expect.sshKeyIDs))
+				}
+			},
+
+			Entry("supports the deprecated SSH fingerprint", &data{
+				spec:   map[string]interface{}{"SSHFingerprint": "03:03"},
+				expect: expect{sshKeyIDs: []int{303}},
+			}),
+			Entry("selects SSH keys by fingerprint, name and label selector", &data{
+				spec: map[string]interface{}{
+					"SSHKeys": []apis.SSHKeySpec{{Fingerprint: "03:03"}, {Name: "team-a-2"}, {LabelSelector: "team=a"}},
+				},
+				expect: expect{sshKeyIDs: []int{303, 302, 301}},
+			}),
+			Entry("fails for unknown SSH key names", &data{
+				spec:   map[string]interface{}{"SSHKeys": []apis.SSHKeySpec{{Name: "unknown"}}},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.InvalidArgument},
+			}),
+			Entry("fails if no SSH key matches the label selector", &data{
+				spec:   map[string]interface{}{"SSHKeys": []apis.SSHKeySpec{{LabelSelector: "team=c"}}},
+				expect: expect{errToHaveOccurred: true, errStatus: codes.InvalidArgument},
+			}),
+			Entry("ignores label selectors without SSH keys if SSH keys are optional", &data{
+				spec: map[string]interface{}{
+					"SSHKeys":         []apis.SSHKeySpec{{LabelSelector: "team=c"}},
+					"SSHKeysOptional": true,
+				},
+				expect: expect{},
+			}),
+			Entry("creates machines without SSH keys if SSH keys are optional", &data{
+				spec:   map[string]interface{}{"SSHKeysOptional": true},
+				expect: expect{},
+			}),
+		)
+	})
+
+	Describe("#GetVolumeIDs", func() {
+		It("should return the volume IDs of HCloud CSI volumes only", func() {
+			ctx := context.Background()
+
+			req := &driver.GetVolumeIDsRequest{
+				PVSpecs: []*corev1.PersistentVolumeSpec{
+					{
+						PersistentVolumeSource: corev1.PersistentVolumeSource{
+							CSI: &corev1.CSIPersistentVolumeSource{
+								Driver:       apis.CSIDriverName,
+								VolumeHandle: "42",
+							},
+						},
+					},
+					{
+						PersistentVolumeSource: corev1.PersistentVolumeSource{
+							CSI: &corev1.CSIPersistentVolumeSource{
+								Driver:       "csi.example.invalid",
+								VolumeHandle: "43",
+							},
+						},
+					},
+					{
+						PersistentVolumeSource: corev1.PersistentVolumeSource{
+							CSI: &corev1.CSIPersistentVolumeSource{
+								Driver:       apis.CSIDriverName,
+								VolumeHandle: "invalid",
+							},
+						},
+					},
+					{
+						PersistentVolumeSource: corev1.PersistentVolumeSource{
+							Local: &corev1.LocalVolumeSource{Path: "/dev/disk/by-id/scsi-0HC_Volume_44"},
+						},
+					},
+					{
+						PersistentVolumeSource: corev1.PersistentVolumeSource{
+							HostPath: &corev1.HostPathVolumeSource{Path: "/mnt/HC_Volume_45/data"},
+						},
+					},
+					{
+						PersistentVolumeSource: corev1.PersistentVolumeSource{
+							Local: &corev1.LocalVolumeSource{Path: "/mnt/disks/ssd0"},
+						},
+					},
+					{
+						PersistentVolumeSource: corev1.PersistentVolumeSource{
+							NFS: &corev1.NFSVolumeSource{Server: "nfs.example.invalid", Path: "/mnt/HC_Volume_46"},
+						},
+					},
+					{
+						Capacity:                      map[corev1.ResourceName]resource.Quantity{},
+						PersistentVolumeSource:        corev1.PersistentVolumeSource{},
+						AccessModes:                   []corev1.PersistentVolumeAccessMode{},
+						ClaimRef:                      &corev1.ObjectReference{},
+						PersistentVolumeReclaimPolicy: "",
+						StorageClassName:              "",
+						MountOptions:                  []string{},
+						NodeAffinity:                  &corev1.VolumeNodeAffinity{},
+					},
+				},
+			}
+
+			resp, err := provider.GetVolumeIDs(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.VolumeIDs).To(Equal([]string{"42"}))
 		})
 	})
 })
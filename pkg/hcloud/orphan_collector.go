@@ -0,0 +1,263 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hcloud contains the HCloud provider specific implementations to manage machines
+package hcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// DefaultOrphanGracePeriod is the minimum age of resources before they are collected as orphans.
+const DefaultOrphanGracePeriod = time.Hour
+
+const (
+	// OrphanKindFloatingIP is the kind of orphaned floating IPs
+	OrphanKindFloatingIP = "floating_ip"
+	// OrphanKindVolume is the kind of orphaned volumes
+	OrphanKindVolume = "volume"
+	// OrphanKindPrimaryIP is the kind of orphaned primary IPs
+	OrphanKindPrimaryIP = "primary_ip"
+)
+
+var orphanCollectorResources = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "mcm",
+	Subsystem: "hcloud_orphan_collector",
+	Name:      "resources_total",
+	Help:      "Number of orphaned resources found partitioned by kind and result (deleted, reported or failed).",
+}, []string{"kind", "result"})
+
+func init() {
+	prometheus.MustRegister(orphanCollectorResources)
+}
+
+// Orphan is a resource of a cluster left behind by a failed or interrupted machine operation
+type Orphan struct {
+	Kind string
+	ID   int
+	Name string
+}
+
+// OrphanCollector deletes floating IPs, volumes and primary IPs of a cluster not belonging to any server anymore.
+// Collections are triggered by the provider whenever MCM lists the machines of a cluster and run at most once per
+// interval for each cluster. The time between two collections is therefore bounded by how often MCM lists machines,
+// e.g. by its safety period for orphaned VMs.
+type OrphanCollector struct {
+	// Interval is the minimum time between two collections of a cluster. Collection is disabled if not positive.
+	Interval time.Duration
+	// GracePeriod is the minimum age of a resource to be collected.
+	GracePeriod time.Duration
+	// DryRun reports orphaned resources only instead of deleting them.
+	DryRun bool
+
+	mutex    sync.Mutex
+	lastRuns map[string]time.Time
+}
+
+// NewOrphanCollector returns a disabled orphan collector with the default grace period.
+func NewOrphanCollector() *OrphanCollector {
+	return &OrphanCollector{
+		GracePeriod: DefaultOrphanGracePeriod,
+		lastRuns:    make(map[string]time.Time),
+	}
+}
+
+// Trigger starts a collection of orphaned resources of the cluster given in the background if the interval passed
+// since the last one. Runs of other clusters older than the interval are forgotten.
+//
+// PARAMETERS
+// client  *hcloud.Client HCloud client
+// cluster string         Cluster name
+func (c *OrphanCollector) Trigger(client *hcloud.Client, cluster string) {
+	if c == nil || c.Interval <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	c.mutex.Lock()
+
+	if lastRun, ok := c.lastRuns[cluster]; ok && now.Sub(lastRun) < c.Interval {
+		c.mutex.Unlock()
+		return
+	}
+
+	// Runs older than the interval do not delay collections anymore and are pruned to not keep clusters gone forever
+	for knownCluster, lastRun := range c.lastRuns {
+		if now.Sub(lastRun) >= c.Interval {
+			delete(c.lastRuns, knownCluster)
+		}
+	}
+
+	c.lastRuns[cluster] = now
+	c.mutex.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.Interval)
+		defer cancel()
+
+		_, err := c.Collect(ctx, client, cluster)
+		if err != nil {
+			klog.Warningf("Failed to collect orphaned resources of cluster %q: %s", cluster, err)
+		}
+	}()
+}
+
+// Collect deletes or reports all orphaned resources of the cluster given and returns them. Floating IPs, volumes and
+// primary IPs are orphaned if they are older than the grace period, not assigned to any server and their machine has
+// no server anymore. Retained volumes and primary IPs of pools are never collected.
+//
+// PARAMETERS
+// ctx     context.Context Execution context
+// client  *hcloud.Client  HCloud client
+// cluster string          Cluster name
+func (c *OrphanCollector) Collect(ctx context.Context, client *hcloud.Client, cluster string) ([]Orphan, error) {
+	clusterSelector := fmt.Sprintf("mcm.gardener.cloud/cluster=%s", cluster)
+
+	servers, err := client.Server.AllWithOpts(ctx, hcloud.ServerListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: clusterSelector + ",mcm.gardener.cloud/role=node", PerPage: 50},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	machines := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		machines[server.Name] = true
+	}
+
+	expiry := time.Now().Add(-c.GracePeriod)
+
+	isOrphaned := func(machineName string, assigned bool, created time.Time) bool {
+		return !assigned && !machines[machineName] && created.Before(expiry)
+	}
+
+	var (
+		orphans []Orphan
+		errs    []error
+	)
+
+	floatingIPs, err := client.FloatingIP.AllWithOpts(ctx, hcloud.FloatingIPListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: clusterSelector + ",networking.hcloud.mcm.gardener.cloud/floating-pool", PerPage: 50},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, floatingIP := range floatingIPs {
+		pool := floatingIP.Labels["networking.hcloud.mcm.gardener.cloud/floating-pool"]
+
+		// Floating IPs are named "<pool>-<machine>-ipv4"
+		machineName, found := strings.CutPrefix(floatingIP.Name, pool+"-")
+		if found {
+			machineName, found = strings.CutSuffix(machineName, "-ipv4")
+		}
+
+		if !found || !isOrphaned(machineName, floatingIP.Server != nil, floatingIP.Created) {
+			continue
+		}
+
+		orphan := Orphan{Kind: OrphanKindFloatingIP, ID: floatingIP.ID, Name: floatingIP.Name}
+		orphans = append(orphans, orphan)
+
+		errs = append(errs, c.collectOrphan(cluster, orphan, func() error {
+			_, err := client.FloatingIP.Delete(ctx, floatingIP)
+			return err
+		}))
+	}
+
+	volumes, err := client.Volume.AllWithOpts(ctx, hcloud.VolumeListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: clusterSelector + ",storage.hcloud.mcm.gardener.cloud/machine,!storage.hcloud.mcm.gardener.cloud/retain",
+			PerPage:       50,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volume := range volumes {
+		if !isOrphaned(volume.Labels["storage.hcloud.mcm.gardener.cloud/machine"], volume.Server != nil, volume.Created) {
+			continue
+		}
+
+		orphan := Orphan{Kind: OrphanKindVolume, ID: volume.ID, Name: volume.Name}
+		orphans = append(orphans, orphan)
+
+		errs = append(errs, c.collectOrphan(cluster, orphan, func() error {
+			_, err := client.Volume.Delete(ctx, volume)
+			return err
+		}))
+	}
+
+	primaryIPs, err := client.PrimaryIP.AllWithOpts(ctx, hcloud.PrimaryIPListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: clusterSelector + ",networking.hcloud.mcm.gardener.cloud/machine", PerPage: 50},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, primaryIP := range primaryIPs {
+		if !isOrphaned(primaryIP.Labels["networking.hcloud.mcm.gardener.cloud/machine"], primaryIP.AssigneeID != 0, primaryIP.Created) {
+			continue
+		}
+
+		orphan := Orphan{Kind: OrphanKindPrimaryIP, ID: primaryIP.ID, Name: primaryIP.Name}
+		orphans = append(orphans, orphan)
+
+		errs = append(errs, c.collectOrphan(cluster, orphan, func() error {
+			_, err := client.PrimaryIP.Delete(ctx, primaryIP)
+			return err
+		}))
+	}
+
+	return orphans, errors.Join(errs...)
+}
+
+// collectOrphan deletes the orphaned resource given or only reports it in dry-run mode
+//
+// PARAMETERS
+// cluster    string       Cluster name
+// orphan     Orphan       Orphaned resource
+// deleteFunc func() error Function deleting the resource
+func (c *OrphanCollector) collectOrphan(cluster string, orphan Orphan, deleteFunc func() error) error {
+	if c.DryRun {
+		klog.Infof("Found orphaned %s %q (%d) of cluster %q", orphan.Kind, orphan.Name, orphan.ID, cluster)
+		orphanCollectorResources.WithLabelValues(orphan.Kind, "reported").Inc()
+
+		return nil
+	}
+
+	err := deleteFunc()
+	if err != nil {
+		orphanCollectorResources.WithLabelValues(orphan.Kind, "failed").Inc()
+		return fmt.Errorf("deletion of orphaned %s %q failed: %w", orphan.Kind, orphan.Name, err)
+	}
+
+	klog.Infof("Deleted orphaned %s %q (%d) of cluster %q", orphan.Kind, orphan.Name, orphan.ID, cluster)
+	orphanCollectorResources.WithLabelValues(orphan.Kind, "deleted").Inc()
+
+	return nil
+}
@@ -0,0 +1,244 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hcloud contains the HCloud provider specific implementations to manage machines
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis/mock"
+)
+
+var _ = Describe("OrphanCollector", func() {
+	var fakeTestEnv mock.MockTestEnv
+
+	type setup struct {
+		kind     string
+		machine  string
+		assigned bool
+		age      time.Duration
+		labels   map[string]string
+	}
+
+	type expect struct {
+		orphaned      bool
+		resourcesLeft int
+	}
+
+	type data struct {
+		setup  setup
+		dryRun bool
+		expect expect
+	}
+
+	var _ = BeforeEach(func() {
+		fakeTestEnv = mock.NewFakeTestEnv()
+	})
+
+	var _ = AfterEach(func() {
+		fakeTestEnv.Teardown()
+	})
+
+	DescribeTable("#Collect",
+		func(data *data) {
+			ctx := context.Background()
+
+			serverID := fakeTestEnv.API.AddServer(schema.Server{
+				Name:       "machine-live",
+				Status:     "running",
+				Datacenter: schema.Datacenter{Name: mock.TestZone},
+				Labels:     map[string]string{"mcm.gardener.cloud/cluster": mock.TestCluster, "mcm.gardener.cloud/role": "node"},
+			})
+
+			var serverRef *int
+			if data.setup.assigned {
+				serverRef = &serverID
+			}
+
+			labels := map[string]string{"mcm.gardener.cloud/cluster": mock.TestCluster}
+			created := time.Now().Add(-data.setup.age)
+			name := ""
+
+			switch data.setup.kind {
+			case OrphanKindFloatingIP:
+				name = fmt.Sprintf("pool-%s-ipv4", data.setup.machine)
+				labels["networking.hcloud.mcm.gardener.cloud/floating-pool"] = "pool"
+				maps.Copy(labels, data.setup.labels)
+
+				fakeTestEnv.API.AddFloatingIP(schema.FloatingIP{Name: name, Type: "ipv4", Server: serverRef, Labels: labels, Created: created})
+			case OrphanKindVolume:
+				name = fmt.Sprintf("%s-volume-0", data.setup.machine)
+				labels["storage.hcloud.mcm.gardener.cloud/machine"] = data.setup.machine
+				maps.Copy(labels, data.setup.labels)
+
+				fakeTestEnv.API.AddVolume(schema.Volume{Name: name, Size: 10, Server: serverRef, Labels: labels, Created: created})
+			case OrphanKindPrimaryIP:
+				name = fmt.Sprintf("%s-ipv4", data.setup.machine)
+				labels["networking.hcloud.mcm.gardener.cloud/machine"] = data.setup.machine
+				maps.Copy(labels, data.setup.labels)
+
+				primaryIP := schema.PrimaryIP{Name: name, Type: "ipv4", Labels: labels, Created: created}
+				if data.setup.assigned {
+					primaryIP.AssigneeID = serverID
+					primaryIP.AssigneeType = "server"
+				}
+
+				fakeTestEnv.API.AddPrimaryIP(primaryIP)
+			}
+
+			collector := NewOrphanCollector()
+			collector.DryRun = data.dryRun
+
+			orphans, err := collector.Collect(ctx, fakeTestEnv.Client, mock.TestCluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			if data.expect.orphaned {
+				Expect(orphans).To(HaveLen(1))
+				Expect(orphans[0].Kind).To(Equal(data.setup.kind))
+				Expect(orphans[0].Name).To(Equal(name))
+			} else {
+				Expect(orphans).To(BeEmpty())
+			}
+
+			resourcesLeft := map[string]int{
+				OrphanKindFloatingIP: len(fakeTestEnv.API.FloatingIPs()),
+				OrphanKindVolume:     len(fakeTestEnv.API.Volumes()),
+				OrphanKindPrimaryIP:  len(fakeTestEnv.API.PrimaryIPs()),
+			}
+
+			Expect(resourcesLeft[data.setup.kind]).To(Equal(data.expect.resourcesLeft))
+			Expect(fakeTestEnv.API.Servers()).To(HaveLen(1))
+		},
+
+		Entry("deletes floating IPs of machines without server", &data{
+			setup:  setup{kind: OrphanKindFloatingIP, machine: "machine-gone", age: 2 * time.Hour},
+			expect: expect{orphaned: true},
+		}),
+		Entry("keeps floating IPs of machines with server", &data{
+			setup:  setup{kind: OrphanKindFloatingIP, machine: "machine-live", age: 2 * time.Hour},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("keeps floating IPs assigned to a server", &data{
+			setup:  setup{kind: OrphanKindFloatingIP, machine: "machine-gone", assigned: true, age: 2 * time.Hour},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("keeps floating IPs within the grace period", &data{
+			setup:  setup{kind: OrphanKindFloatingIP, machine: "machine-gone", age: time.Minute},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("keeps floating IPs of other clusters", &data{
+			setup: setup{
+				kind:    OrphanKindFloatingIP,
+				machine: "machine-gone",
+				age:     2 * time.Hour,
+				labels:  map[string]string{"mcm.gardener.cloud/cluster": "other"},
+			},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("keeps floating IPs not named after their pool", &data{
+			setup: setup{
+				kind:    OrphanKindFloatingIP,
+				machine: "machine-gone",
+				age:     2 * time.Hour,
+				labels:  map[string]string{"networking.hcloud.mcm.gardener.cloud/floating-pool": "other"},
+			},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("deletes volumes of machines without server", &data{
+			setup:  setup{kind: OrphanKindVolume, machine: "machine-gone", age: 2 * time.Hour},
+			expect: expect{orphaned: true},
+		}),
+		Entry("keeps volumes attached to a server", &data{
+			setup:  setup{kind: OrphanKindVolume, machine: "machine-gone", assigned: true, age: 2 * time.Hour},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("keeps retained volumes", &data{
+			setup: setup{
+				kind:    OrphanKindVolume,
+				machine: "machine-gone",
+				age:     2 * time.Hour,
+				labels:  map[string]string{"storage.hcloud.mcm.gardener.cloud/retain": "true"},
+			},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("deletes primary IPs of machines without server", &data{
+			setup:  setup{kind: OrphanKindPrimaryIP, machine: "machine-gone", age: 2 * time.Hour},
+			expect: expect{orphaned: true},
+		}),
+		Entry("keeps primary IPs assigned to a server", &data{
+			setup:  setup{kind: OrphanKindPrimaryIP, machine: "machine-gone", assigned: true, age: 2 * time.Hour},
+			expect: expect{resourcesLeft: 1},
+		}),
+		Entry("reports orphans without deleting them in dry-run mode", &data{
+			setup:  setup{kind: OrphanKindVolume, machine: "machine-gone", age: 2 * time.Hour},
+			dryRun: true,
+			expect: expect{orphaned: true, resourcesLeft: 1},
+		}),
+	)
+
+	Describe("#Trigger", func() {
+		It("should not collect orphans if disabled", func() {
+			fakeTestEnv.API.AddFloatingIP(schema.FloatingIP{
+				Name:   "pool-machine-gone-ipv4",
+				Type:   "ipv4",
+				Labels: map[string]string{"mcm.gardener.cloud/cluster": mock.TestCluster, "networking.hcloud.mcm.gardener.cloud/floating-pool": "pool"},
+			})
+
+			NewOrphanCollector().Trigger(fakeTestEnv.Client, mock.TestCluster)
+			Consistently(fakeTestEnv.API.FloatingIPs, 100*time.Millisecond).Should(HaveLen(1))
+		})
+
+		It("should collect orphans at most once per interval", func() {
+			collector := NewOrphanCollector()
+			collector.Interval = time.Hour
+
+			labels := map[string]string{"mcm.gardener.cloud/cluster": mock.TestCluster, "networking.hcloud.mcm.gardener.cloud/floating-pool": "pool"}
+
+			fakeTestEnv.API.AddFloatingIP(schema.FloatingIP{Name: "pool-machine-a-ipv4", Type: "ipv4", Labels: labels})
+
+			collector.Trigger(fakeTestEnv.Client, mock.TestCluster)
+			Eventually(fakeTestEnv.API.FloatingIPs).Should(BeEmpty())
+
+			fakeTestEnv.API.AddFloatingIP(schema.FloatingIP{Name: "pool-machine-b-ipv4", Type: "ipv4", Labels: labels})
+
+			collector.Trigger(fakeTestEnv.Client, mock.TestCluster)
+			Consistently(fakeTestEnv.API.FloatingIPs, 100*time.Millisecond).Should(HaveLen(1))
+		})
+
+		It("should forget runs of clusters older than the interval", func() {
+			collector := NewOrphanCollector()
+			collector.Interval = 50 * time.Millisecond
+
+			collector.Trigger(fakeTestEnv.Client, "cluster-a")
+			collector.Trigger(fakeTestEnv.Client, "cluster-b")
+			Expect(collector.lastRuns).To(HaveLen(2))
+
+			time.Sleep(collector.Interval)
+
+			collector.Trigger(fakeTestEnv.Client, "cluster-b")
+			Expect(collector.lastRuns).To(HaveLen(1))
+			Expect(collector.lastRuns).To(HaveKey("cluster-b"))
+		})
+	})
+})
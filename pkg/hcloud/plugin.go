@@ -23,11 +23,13 @@ import (
 
 // MachineProvider is the struct that implements the driver interface
 type MachineProvider struct {
+	orphanCollector *OrphanCollector
 }
 
 // NewHCloudProvider returns a provider object.
 //
 // PARAMETERS
-func NewHCloudProvider() driver.Driver {
-	return &MachineProvider{}
+// orphanCollector *OrphanCollector Collector of orphaned resources triggered by the provider (may be nil)
+func NewHCloudProvider(orphanCollector *OrphanCollector) driver.Driver {
+	return &MachineProvider{orphanCollector: orphanCollector}
 }
@@ -26,7 +26,7 @@ import (
 var _ = Describe("Plugin", func() {
 	Describe("#NewHCloudProvider", func() {
 		It("should correctly create a new provider object", func() {
-			provider := NewHCloudProvider()
+			provider := NewHCloudProvider(NewOrphanCollector())
 			Expect(provider).NotTo(BeNil(), "NewHCloudProvider should not return nil")
 			_, ok := provider.(driver.Driver)
 			Expect(ok).To(BeTrue(), "The returned provider should implement driver.Driver")
@@ -0,0 +1,225 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hcloud contains the HCloud provider specific implementations to manage machines
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+
+	"github.com/23technologies/machine-controller-manager-provider-hcloud/pkg/hcloud/apis"
+)
+
+// serverPreflight executes the pre-flight checks configured for server candidates
+type serverPreflight struct {
+	client      *hcloud.Client
+	spec        *apis.PreflightSpec
+	cores       int
+	datacenters map[string]*hcloud.Datacenter
+	serverTypes map[string]*hcloud.ServerType
+}
+
+// getServerCandidates returns the server types and zones to try in order of preference. Candidates failing the
+// pre-flight checks configured are skipped and an error is returned if none of them is left.
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// client       *hcloud.Client     HCloud client
+// providerSpec *apis.ProviderSpec Provider specification
+func (p *MachineProvider) getServerCandidates(ctx context.Context, client *hcloud.Client, providerSpec *apis.ProviderSpec) ([]serverCandidate, error) {
+	var candidates []serverCandidate
+
+	for _, serverType := range apis.GetServerTypes(providerSpec) {
+		for _, zone := range apis.GetZones(providerSpec) {
+			candidates = append(candidates, serverCandidate{serverType: serverType, zone: zone})
+		}
+	}
+
+	if providerSpec.Preflight == nil {
+		return candidates, nil
+	}
+
+	check := &serverPreflight{
+		client:      client,
+		spec:        providerSpec.Preflight,
+		datacenters: make(map[string]*hcloud.Datacenter),
+		serverTypes: make(map[string]*hcloud.ServerType),
+	}
+
+	if err := check.checkProjectLimits(ctx); err != nil {
+		return nil, err
+	}
+
+	var (
+		availableCandidates []serverCandidate
+		reasons             []string
+	)
+
+	for _, candidate := range candidates {
+		reason, err := check.checkCandidate(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason != "" {
+			reasons = append(reasons, reason)
+			continue
+		}
+
+		availableCandidates = append(availableCandidates, candidate)
+	}
+
+	if len(availableCandidates) == 0 {
+		return nil, status.Error(codes.ResourceExhausted, fmt.Sprintf("Pre-flight checks failed: %s", strings.Join(reasons, "; ")))
+	}
+
+	return availableCandidates, nil
+}
+
+// checkProjectLimits returns an error if the project server limit declared by the provider spec has been reached. The
+// number of CPU cores in use is recorded for candidate checks.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (check *serverPreflight) checkProjectLimits(ctx context.Context) error {
+	if check.spec.MaxServers == 0 && check.spec.MaxCores == 0 {
+		return nil
+	}
+
+	servers, err := check.client.Server.All(ctx)
+	if err != nil {
+		return getStatusForError(codes.Unavailable, err)
+	}
+
+	if check.spec.MaxServers > 0 && len(servers) >= check.spec.MaxServers {
+		return status.Error(codes.ResourceExhausted, fmt.Sprintf("Project server limit of %d reached with %d servers", check.spec.MaxServers, len(servers)))
+	}
+
+	for _, server := range servers {
+		if server.ServerType != nil {
+			check.cores += server.ServerType.Cores
+		}
+	}
+
+	return nil
+}
+
+// checkCandidate returns the reason why a server can not be created with the candidate given or an empty string.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// candidate serverCandidate Server type and zone to check
+func (check *serverPreflight) checkCandidate(ctx context.Context, candidate serverCandidate) (string, error) {
+	if !check.spec.ServerType && check.spec.MaxCores == 0 {
+		return "", nil
+	}
+
+	serverType, err := check.getServerType(ctx, candidate.serverType)
+	if err != nil {
+		return "", err
+	}
+
+	if check.spec.MaxCores > 0 && check.cores+serverType.Cores > check.spec.MaxCores {
+		return fmt.Sprintf("server type %s needs %d cores but only %d of the project core limit of %d are left", serverType.Name, serverType.Cores, max(check.spec.MaxCores-check.cores, 0), check.spec.MaxCores), nil
+	}
+
+	if !check.spec.ServerType {
+		return "", nil
+	}
+
+	datacenter, err := check.getDatacenter(ctx, candidate.zone)
+	if err != nil {
+		return "", err
+	}
+
+	isAvailable := false
+	for _, availableServerType := range datacenter.ServerTypes.Available {
+		if availableServerType.ID == serverType.ID {
+			isAvailable = true
+			break
+		}
+	}
+
+	if !isAvailable {
+		return fmt.Sprintf("server type %s is not available in %s", serverType.Name, datacenter.Name), nil
+	}
+
+	if datacenter.Location != nil {
+		isPriced := false
+		for _, pricing := range serverType.Pricings {
+			if pricing.Location != nil && pricing.Location.Name == datacenter.Location.Name {
+				isPriced = true
+				break
+			}
+		}
+
+		if !isPriced {
+			return fmt.Sprintf("server type %s has no pricing for location %s", serverType.Name, datacenter.Location.Name), nil
+		}
+	}
+
+	return "", nil
+}
+
+// getDatacenter returns the datacenter with the name given.
+//
+// PARAMETERS
+// ctx  context.Context Execution context
+// name string          Datacenter name
+func (check *serverPreflight) getDatacenter(ctx context.Context, name string) (*hcloud.Datacenter, error) {
+	if datacenter, ok := check.datacenters[name]; ok {
+		return datacenter, nil
+	}
+
+	datacenter, _, err := check.client.Datacenter.GetByName(ctx, name)
+	if err != nil {
+		return nil, getStatusForError(codes.Unavailable, err)
+	} else if datacenter == nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Datacenter %s not found", name))
+	}
+
+	check.datacenters[name] = datacenter
+
+	return datacenter, nil
+}
+
+// getServerType returns the server type with the name given.
+//
+// PARAMETERS
+// ctx  context.Context Execution context
+// name string          Server type name
+func (check *serverPreflight) getServerType(ctx context.Context, name string) (*hcloud.ServerType, error) {
+	if serverType, ok := check.serverTypes[name]; ok {
+		return serverType, nil
+	}
+
+	serverType, _, err := check.client.ServerType.GetByName(ctx, name)
+	if err != nil {
+		return nil, getStatusForError(codes.Unavailable, err)
+	} else if serverType == nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Server type %s not found", name))
+	}
+
+	check.serverTypes[name] = serverType
+
+	return serverType, nil
+}
@@ -17,9 +17,22 @@ limitations under the License.
 // Package hcloud contains the HCloud provider specific implementations to manage machines
 package hcloud
 
+import (
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
 type CreateMachineMethodData struct {
 	ServerID     int
 	FloatingIPID int
+	VolumeIDs    []int
 }
 
 type CtxWrapDataKey string
+
+// serverCandidate is a combination of server type and zone a server may be created with
+type serverCandidate struct {
+	serverType string
+	zone       string
+	// image is the image matching the architecture of the server type
+	image *hcloud.Image
+}